@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+	"task-manager/internal/transfer"
+	"task-manager/internal/utils"
+)
+
+const usage = `usage:
+  task_manager                 run the server
+  task_manager export [flags]  write a user's tasks to a file or stdout
+  task_manager import [flags] [file]
+                               create tasks for a user from a file or stdin
+
+Both commands use the server's storage settings. Run a command with -h for
+its flags.
+`
+
+// formats by file extension, for when -format is not given
+var extensionFormats = map[string]string{
+	".csv":  transfer.FormatCSV,
+	".json": transfer.FormatJSON,
+	".ics":  transfer.FormatICS,
+	".txt":  transfer.FormatTodoTxt,
+	".md":   transfer.FormatMarkdown,
+}
+
+// runCommand runs a subcommand of the server binary and returns its exit
+// status.
+func runCommand(args []string) int {
+	var err error
+	switch args[0] {
+	case "export":
+		err = exportCommand(args[1:])
+	case "import":
+		err = importCommand(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	email := fs.String("user", "", "email of the user whose tasks to export (required)")
+	format := fs.String("format", "", "one of "+strings.Join(transfer.Formats, ", ")+"; taken from -o's extension when not given, todotxt otherwise")
+	query := fs.String("q", "", `filter expression, such as "status:pending due<+7d"`)
+	output := fs.String("o", "", "file to write, instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("export takes -user and no arguments")
+	}
+
+	f := fileFormat(*format, *output)
+	if !slices.Contains(transfer.Formats, f) {
+		return fmt.Errorf("format must be one of: %s", strings.Join(transfer.Formats, ", "))
+	}
+
+	ctx, tasks, closeStores, err := commandContext(*email)
+	if err != nil {
+		return err
+	}
+	defer closeStores(context.Background())
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	enc := transfer.NewEncoder(f, w)
+
+	filters := map[string]string{"q": *query}
+	if f == transfer.FormatMarkdown {
+		filters["sort"] = "category"
+	}
+	if err := tasks.ExportTasks(ctx, filters, enc.Encode); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func importCommand(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	email := fs.String("user", "", "email of the user to create the tasks for (required)")
+	format := fs.String("format", "", "one of "+strings.Join(transfer.Formats, ", ")+"; taken from the file's extension when not given, todotxt otherwise")
+	dryRun := fs.Bool("dry-run", false, "check every task without creating any")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("import takes -user and at most one file")
+	}
+
+	var r io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	f := fileFormat(*format, fs.Arg(0))
+	rows, err := transfer.Decode(f, r)
+	if err != nil {
+		return fmt.Errorf("invalid %s file: %w", f, err)
+	}
+
+	ctx, tasks, closeStores, err := commandContext(*email)
+	if err != nil {
+		return err
+	}
+	defer closeStores(context.Background())
+
+	report, err := tasks.ImportTasks(ctx, rows, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		if row.Status != models.ImportInvalid {
+			continue
+		}
+		problems := []string{}
+		if row.Error != "" {
+			problems = append(problems, row.Error)
+		}
+		if errs, ok := row.Errors.([]utils.ValidationErrType); ok {
+			for _, e := range errs {
+				problems = append(problems, e.Message)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "line %d: %s\n", row.Row, strings.Join(problems, "; "))
+	}
+	fmt.Printf("%d tasks: %d created, %d valid, %d duplicates, %d invalid\n",
+		report.Total, report.Created, report.Valid, report.Duplicates, report.Invalid)
+	if report.Invalid > 0 {
+		return fmt.Errorf("%d tasks were not imported", report.Invalid)
+	}
+	return nil
+}
+
+// fileFormat is format, or else the format path's extension stands for.
+func fileFormat(format, path string) string {
+	if format != "" {
+		return format
+	}
+	if f, ok := extensionFormats[strings.ToLower(filepath.Ext(path))]; ok {
+		return f
+	}
+	return transfer.FormatTodoTxt
+}
+
+// commandContext opens the configured storage and returns a context acting
+// as the user with email, and the task service to act with.
+func commandContext(email string) (context.Context, *services.TaskService, func(context.Context) error, error) {
+	stores, closeStores := openStores(loadConfig())
+
+	ctx := context.Background()
+	user, _ := stores.Users.GetUserByEmail(ctx, email)
+	if user == nil {
+		closeStores(ctx)
+		return nil, nil, nil, fmt.Errorf("no user with email %s", email)
+	}
+
+	tasks := services.NewTaskService(stores.Tasks, stores.Projects, stores.Users, stores.Comments, stores.History)
+	return services.WithUser(ctx, user), tasks, closeStores, nil
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -28,26 +29,34 @@ func main() {
 		}
 	}
 
-	cfg := config.Primary{
-		MongoUri: os.Getenv("MONGO_URI"),
-		Database: os.Getenv("DATABASE_NAME"),
-		Port:     os.Getenv("PORT"),
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
 	}
 
-	client, err := database.Connect(cfg.MongoUri)
-	if err != nil {
-		log.Fatalln("Error Connecting to database:", err)
-	}
-	defer client.Disconnect(context.Background())
+	cfg := loadConfig()
+	stores, closeStores := openStores(cfg)
 
-	taskRepo := repository.NewTaskRespository(client, cfg.Database)
-	taskService := services.NewTaskService(taskRepo)
+	taskService := services.NewTaskService(stores.Tasks, stores.Projects, stores.Users, stores.Comments, stores.History)
 	taskHandler := handlers.NewTaskHandler(taskService)
 
-	userRepo := repository.NewUserRespository(client, cfg.Database)
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(stores.Users)
 	userHandler := handlers.NewUserHandler(userService)
 
+	projectService := services.NewProjectService(stores.Projects, taskService, stores.Users)
+	projectHandler := handlers.NewProjectHandler(projectService)
+
+	commentService := services.NewCommentService(stores.Comments, taskService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+
+	viewService := services.NewViewService(stores.Views, taskService)
+	viewHandler := handlers.NewViewHandler(viewService)
+
+	calendarService := services.NewCalendarService(stores.Users, taskService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+
+	reminderService := services.NewReminderService(stores.Tasks, stores.Users, stores.Notifications)
+	digestService := services.NewDigestService(stores.Tasks, stores.Users, stores.Notifications)
+
 	mux := http.NewServeMux()
 	limiter := middleware.NewRateLimiter(1, 2.0)
 	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
@@ -56,6 +65,10 @@ func main() {
 
 	routes.TaskRouter(mux, taskHandler)
 	routes.UserRouter(mux, userHandler)
+	routes.ProjectRouter(mux, projectHandler)
+	routes.CommentRouter(mux, commentHandler)
+	routes.ViewRouter(mux, viewHandler)
+	routes.CalendarRouter(mux, calendarHandler)
 
 	secureMux := middleware.ApplyMiddleware(mux, limiter.LimitMiddleware, middleware.JWTMiddleware)
 
@@ -71,6 +84,11 @@ func main() {
 	)
 	defer stop()
 
+	var workers sync.WaitGroup
+	workers.Go(func() { taskService.RunPurger(ctx, cfg.TrashRetention, time.Hour) })
+	workers.Go(func() { reminderService.RunReminders(ctx, cfg.ReminderInterval) })
+	workers.Go(func() { digestService.RunDigests(ctx, cfg.ReminderInterval) })
+
 	go func() {
 		fmt.Printf("Server running on port :%s...\n", cfg.Port)
 		err := server.ListenAndServe()
@@ -88,15 +106,97 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	err = server.Shutdown(shutdownCtx)
+	err := server.Shutdown(shutdownCtx)
 	if err != nil {
 		log.Println("Server forced to shutdown:", err)
 	}
 
-	err = client.Disconnect(shutdownCtx)
+	// the background jobs stop with ctx; let them finish before the database goes away
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		log.Println("Background jobs did not stop in time")
+	}
+
+	err = closeStores(shutdownCtx)
 	if err != nil {
 		log.Println("Error closing database:", err)
 	}
 
 	fmt.Println("Shutdown complete.")
 }
+
+func loadConfig() config.Primary {
+	cfg := config.Primary{
+		Storage:    os.Getenv("STORAGE"),
+		MongoUri:   os.Getenv("MONGO_URI"),
+		SQLitePath: os.Getenv("SQLITE_PATH"),
+		Database:   os.Getenv("DATABASE_NAME"),
+		Port:       os.Getenv("PORT"),
+	}
+
+	// trashed tasks are purged after 30 days unless TRASH_RETENTION (e.g. "168h") says otherwise
+	cfg.TrashRetention = 30 * 24 * time.Hour
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		retention, err := time.ParseDuration(v)
+		if err != nil || retention <= 0 {
+			log.Fatalln("Invalid TRASH_RETENTION:", v)
+		}
+		cfg.TrashRetention = retention
+	}
+
+	// due-date reminders and digests are checked every minute unless REMINDER_INTERVAL says otherwise
+	cfg.ReminderInterval = time.Minute
+	if v := os.Getenv("REMINDER_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatalln("Invalid REMINDER_INTERVAL:", v)
+		}
+		cfg.ReminderInterval = interval
+	}
+	return cfg
+}
+
+// openStores connects to the configured storage backend. The returned
+// function closes the connection.
+func openStores(cfg config.Primary) (*repository.Stores, func(context.Context) error) {
+	switch cfg.Storage {
+	case "memory":
+		// logged to stderr, like the other backends, so that the export
+		// command can write to stdout
+		log.Println("Using in-memory storage")
+		return repository.NewMemoryStores(), func(context.Context) error { return nil }
+	case "", "mongo":
+		client, err := database.Connect(cfg.MongoUri)
+		if err != nil {
+			log.Fatalln("Error Connecting to database:", err)
+		}
+
+		stores := repository.NewMongoStores(client, cfg.Database)
+		if err := stores.EnsureIndexes(context.Background()); err != nil {
+			log.Fatalln("Error creating indexes:", err)
+		}
+		return stores, client.Disconnect
+	case "sqlite":
+		if cfg.SQLitePath == "" {
+			cfg.SQLitePath = "task-manager.db"
+		}
+		sqlDB, err := database.ConnectSQLite(cfg.SQLitePath)
+		if err != nil {
+			log.Fatalln("Error Connecting to database:", err)
+		}
+
+		stores, err := repository.NewSQLiteStores(sqlDB)
+		if err != nil {
+			log.Fatalln("Error preparing sqlite tables:", err)
+		}
+		return stores, func(context.Context) error { return sqlDB.Close() }
+	}
+	log.Fatalln("Unknown storage backend:", cfg.Storage)
+	return nil, nil
+}
@@ -1,8 +1,14 @@
 package config
 
+import "time"
+
 type Primary struct {
-	MongoUri  string
-	Database  string
-	Port      string
-	JWTSecret string
+	Storage          string
+	MongoUri         string
+	SQLitePath       string
+	Database         string
+	Port             string
+	JWTSecret        string
+	TrashRetention   time.Duration
+	ReminderInterval time.Duration
 }
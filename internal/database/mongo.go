@@ -1,7 +1,7 @@
 package database
 
 import (
-	"fmt"
+	"log"
 
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -13,6 +13,6 @@ func Connect(uri string) (*mongo.Client, error) {
 		return nil, err
 	}
 
-	fmt.Println("Connected to database")
+	log.Println("Connected to database")
 	return client, nil
 }
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+	"task-manager/internal/transfer"
+	"task-manager/internal/utils"
+)
+
+type CalendarHandler struct {
+	Service *services.CalendarService
+}
+
+func NewCalendarHandler(s *services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{Service: s}
+}
+
+// create or rotate the caller's feed token and return the url to subscribe to
+func (h *CalendarHandler) CreateFeedToken(w http.ResponseWriter, r *http.Request) error {
+	token, err := h.Service.CreateFeedToken(r.Context())
+	if err != nil {
+		return err
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	feed := url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     "/api/calendar/feed.ics",
+		RawQuery: url.Values{"token": {token}}.Encode(),
+	}
+
+	utils.ResponseJSON(w, http.StatusCreated, "Calendar feed created", struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}{
+		Token: token,
+		URL:   feed.String(),
+	})
+	return nil
+}
+
+func (h *CalendarHandler) RevokeFeedToken(w http.ResponseWriter, r *http.Request) error {
+	if err := h.Service.RevokeFeedToken(r.Context()); err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Calendar feed revoked", nil)
+	return nil
+}
+
+// the feed itself, authenticated by ?token rather than a bearer token.
+// Tasks are VTODOs unless ?component=event asks for VEVENTs, which more
+// calendar apps show.
+func (h *CalendarHandler) GetFeed(w http.ResponseWriter, r *http.Request) error {
+	component := transfer.ComponentTodo
+	switch strings.ToLower(r.URL.Query().Get("component")) {
+	case "", "todo":
+	case "event":
+		component = transfer.ComponentEvent
+	default:
+		return utils.BadRequest("component must be todo or event", nil)
+	}
+
+	enc := transfer.NewCalendarEncoder(w, component)
+	return streamTasks(w, "text/calendar; charset=utf-8", enc, func(emit func([]models.Task) error) error {
+		return h.Service.Feed(r.Context(), r.URL.Query().Get("token"), emit)
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+	"task-manager/internal/utils"
+	"task-manager/internal/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CommentHandler struct {
+	Service *services.CommentService
+}
+
+func NewCommentHandler(s *services.CommentService) *CommentHandler {
+	return &CommentHandler{Service: s}
+}
+
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) error {
+	taskId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	comments, err := h.Service.GetComments(r.Context(), taskId)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Comments", struct {
+		Count    int              `json:"count"`
+		Comments []models.Comment `json:"comments"`
+	}{
+		Count:    len(comments),
+		Comments: comments,
+	})
+	return nil
+}
+
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) error {
+	taskId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	var body models.CommentRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	comment, err := h.Service.CreateComment(r.Context(), taskId, &body)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusCreated, "Comment created", comment)
+	return nil
+}
+
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) error {
+	taskId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	commentId, err := primitive.ObjectIDFromHex(r.PathValue("commentId"))
+	if err != nil {
+		return utils.BadRequest("Invalid comment id", nil)
+	}
+
+	var body models.CommentRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	comment, err := h.Service.UpdateComment(r.Context(), taskId, commentId, &body)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Comment updated", comment)
+	return nil
+}
+
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) error {
+	taskId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	commentId, err := primitive.ObjectIDFromHex(r.PathValue("commentId"))
+	if err != nil {
+		return utils.BadRequest("Invalid comment id", nil)
+	}
+
+	err = h.Service.DeleteComment(r.Context(), taskId, commentId)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Comment deleted", nil)
+	return nil
+}
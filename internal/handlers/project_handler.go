@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net/http"
+
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+	"task-manager/internal/utils"
+	"task-manager/internal/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ProjectHandler struct {
+	Service *services.ProjectService
+}
+
+func NewProjectHandler(s *services.ProjectService) *ProjectHandler {
+	return &ProjectHandler{Service: s}
+}
+
+func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) error {
+	var project models.CreateProjectRequest
+
+	err := DecodeStrict(r.Body, &project)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(project)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	created, err := h.Service.CreateProject(r.Context(), &project)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusCreated, "Project created", created)
+	return nil
+}
+
+// list projects, archived ones only with ?archived=true
+func (h *ProjectHandler) GetProjects(w http.ResponseWriter, r *http.Request) error {
+	includeArchived := r.URL.Query().Get("archived") == "true"
+
+	projects, err := h.Service.GetProjects(r.Context(), includeArchived)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Projects", struct {
+		Count    int              `json:"count"`
+		Projects []models.Project `json:"projects"`
+	}{
+		Count:    len(projects),
+		Projects: projects,
+	})
+	return nil
+}
+
+func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid project id", nil)
+	}
+
+	project, err := h.Service.GetProject(r.Context(), objectId)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Project", project)
+	return nil
+}
+
+func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid project id", nil)
+	}
+
+	var body models.UpdateProjectRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	project, err := h.Service.UpdateProject(r.Context(), objectId, body)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Project updated", project)
+	return nil
+}
+
+// delete a project, ?tasks=move (default) moves its tasks to the inbox and
+// ?tasks=delete moves them to the trash
+func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid project id", nil)
+	}
+
+	err = h.Service.DeleteProject(r.Context(), objectId, r.URL.Query().Get("tasks"))
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Project Deleted", nil)
+	return nil
+}
+
+// share a project with a registered user by email
+func (h *ProjectHandler) InviteMember(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid project id", nil)
+	}
+
+	var body models.InviteMemberRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	project, err := h.Service.InviteMember(r.Context(), objectId, &body)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Member invited", project)
+	return nil
+}
+
+func (h *ProjectHandler) UpdateMember(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid project id", nil)
+	}
+
+	userId, err := primitive.ObjectIDFromHex(r.PathValue("userId"))
+	if err != nil {
+		return utils.BadRequest("Invalid user id", nil)
+	}
+
+	var body models.UpdateMemberRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	project, err := h.Service.UpdateMember(r.Context(), objectId, userId, body.Role)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Member updated", project)
+	return nil
+}
+
+func (h *ProjectHandler) RemoveMember(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid project id", nil)
+	}
+
+	userId, err := primitive.ObjectIDFromHex(r.PathValue("userId"))
+	if err != nil {
+		return utils.BadRequest("Invalid user id", nil)
+	}
+
+	err = h.Service.RemoveMember(r.Context(), objectId, userId)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Member removed", nil)
+	return nil
+}
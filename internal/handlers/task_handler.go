@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"task-manager/internal/models"
 	"task-manager/internal/services"
@@ -47,13 +52,24 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	setETag(w, created)
 	utils.ResponseJSON(w, http.StatusCreated, "Task created", created)
 	return nil
 }
 
-// get tasks, filter, sort, paginate
+// get tasks, filter, sort, paginate by cursor or page
 func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) error {
+	page, err := h.Service.GetTasks(r.Context(), taskFilters(r))
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Tasks", page)
+	return nil
+}
 
+// taskFilters reads the GET /api/tasks query parameters.
+func taskFilters(r *http.Request) map[string]string {
 	filters := map[string]string{
 		"category": "",
 		"status":   "",
@@ -62,6 +78,15 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) error {
 		"sort":     "",
 		"order":    "",
 		"search":   "",
+		"q":        "",
+		"view":     "",
+		"blocked":  "",
+		"tag":      "",
+		"tag_mode": "",
+		"project":  "",
+		"assigned": "",
+		"cursor":   "",
+		"total":    "",
 	}
 
 	for key := range filters {
@@ -69,13 +94,68 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) error {
 			filters[key] = val[0]
 		}
 	}
+	return filters
+}
+
+// get a single task by id
+func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	task, err := h.Service.GetTask(r.Context(), objectId)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Task", task)
+	return nil
+}
+
+// create a subtask under the task in the path
+func (h *TaskHandler) CreateSubtask(w http.ResponseWriter, r *http.Request) error {
+	parentId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	var task models.CreateTaskRequest
+	err = DecodeStrict(r.Body, &task)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(task)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	created, err := h.Service.CreateSubtask(r.Context(), parentId, &task)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, created)
+	utils.ResponseJSON(w, http.StatusCreated, "Subtask created", created)
+	return nil
+}
+
+// list the direct children of a task
+func (h *TaskHandler) GetSubtasks(w http.ResponseWriter, r *http.Request) error {
+	parentId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
 
-	tasks, err := h.Service.GetTasks(r.Context(), filters)
+	tasks, err := h.Service.GetSubtasks(r.Context(), parentId)
 	if err != nil {
 		return err
 	}
 
-	utils.ResponseJSON(w, http.StatusOK, "Tasks", struct {
+	utils.ResponseJSON(w, http.StatusOK, "Subtasks", struct {
 		Count int           `json:"count"`
 		Tasks []models.Task `json:"tasks"`
 	}{
@@ -85,6 +165,324 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// add tags to a task without rewriting it
+func (h *TaskHandler) AddTags(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	var body models.TagsRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.AddTags(r.Context(), objectId, body.Tags, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Tags added", task)
+	return nil
+}
+
+func (h *TaskHandler) RemoveTag(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.RemoveTag(r.Context(), objectId, r.PathValue("tag"), ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Tag removed", task)
+	return nil
+}
+
+// list the caller's tags with usage counts
+func (h *TaskHandler) GetTags(w http.ResponseWriter, r *http.Request) error {
+	tags, err := h.Service.GetTags(r.Context())
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Tags", tags)
+	return nil
+}
+
+// mark the task in the path as blocked by another task
+func (h *TaskHandler) AddDependency(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	var body models.AddDependencyRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	blockerId, err := primitive.ObjectIDFromHex(body.BlockerID)
+	if err != nil {
+		return utils.BadRequest("Invalid blocker id", nil)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.AddDependency(r.Context(), objectId, blockerId, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Dependency added", task)
+	return nil
+}
+
+func (h *TaskHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	blockerId, err := primitive.ObjectIDFromHex(r.PathValue("blockerId"))
+	if err != nil {
+		return utils.BadRequest("Invalid blocker id", nil)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.RemoveDependency(r.Context(), objectId, blockerId, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Dependency removed", task)
+	return nil
+}
+
+// assign a registered user to the task
+func (h *TaskHandler) AssignTask(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	var body models.AssignTaskRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.AssignTask(r.Context(), objectId, body.Email, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Task assigned", task)
+	return nil
+}
+
+// replace all assignees of the task
+func (h *TaskHandler) ReassignTask(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	var body models.ReassignTaskRequest
+	err = DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.ReassignTask(r.Context(), objectId, body.Emails, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Task reassigned", task)
+	return nil
+}
+
+func (h *TaskHandler) UnassignTask(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	userId, err := primitive.ObjectIDFromHex(r.PathValue("userId"))
+	if err != nil {
+		return utils.BadRequest("Invalid user id", nil)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.UnassignTask(r.Context(), objectId, userId, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Task unassigned", task)
+	return nil
+}
+
+// list the caller's trashed tasks
+func (h *TaskHandler) GetTrash(w http.ResponseWriter, r *http.Request) error {
+	tasks, err := h.Service.GetTrash(r.Context())
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Trash", struct {
+		Count int           `json:"count"`
+		Tasks []models.Task `json:"tasks"`
+	}{
+		Count: len(tasks),
+		Tasks: tasks,
+	})
+	return nil
+}
+
+// take a task out of the trash
+func (h *TaskHandler) RestoreTask(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.RestoreTask(r.Context(), objectId, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Task restored", task)
+	return nil
+}
+
+// list the recorded changes to a task
+func (h *TaskHandler) GetHistory(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	history, err := h.Service.GetHistory(r.Context(), objectId)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Task history", history)
+	return nil
+}
+
+// apply one action to many tasks, reporting the outcome per task
+func (h *TaskHandler) BulkTasks(w http.ResponseWriter, r *http.Request) error {
+	var body models.BulkTaskRequest
+	err := DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	results, err := h.Service.BulkTasks(r.Context(), &body)
+	if err != nil {
+		return err
+	}
+
+	succeeded := 0
+	for _, res := range results {
+		if res.Success {
+			succeeded++
+		}
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Bulk operation finished", struct {
+		Succeeded int                 `json:"succeeded"`
+		Failed    int                 `json:"failed"`
+		Results   []models.BulkResult `json:"results"`
+	}{
+		Succeeded: succeeded,
+		Failed:    len(results) - succeeded,
+		Results:   results,
+	})
+	return nil
+}
+
 // update task by id
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) error {
 	id := r.PathValue("id")
@@ -104,11 +502,66 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) error {
 		return utils.BadRequest("Invalid JSON", nil)
 	}
 
-	task, err := h.Service.UpdateTask(r.Context(), objectId, body)
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.UpdateTask(r.Context(), objectId, body, ifMatch)
 	if err != nil {
 		return err
 	}
 
+	setETag(w, task)
+	utils.ResponseJSON(w, http.StatusOK, "Task updated", task)
+	return nil
+}
+
+// patch a task with a JSON Merge Patch (RFC 7396); null clears a field
+func (h *TaskHandler) PatchTask(w http.ResponseWriter, r *http.Request) error {
+	objectId, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return utils.BadRequest("Invalid task id", nil)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/merge-patch+json" {
+		return utils.NewAppError(http.StatusUnsupportedMediaType, "Content-Type must be application/merge-patch+json", nil)
+	}
+
+	var members map[string]json.RawMessage
+	err = json.NewDecoder(r.Body).Decode(&members)
+	if err != nil || members == nil {
+		return utils.BadRequest("Merge patch must be a JSON object", nil)
+	}
+
+	var patch models.TaskPatch
+	for name, value := range members {
+		if string(value) == "null" {
+			patch.Clear = append(patch.Clear, name)
+			delete(members, name)
+		}
+	}
+	slices.Sort(patch.Clear)
+
+	// decode the remaining members strictly so unknown fields are rejected
+	rest, _ := json.Marshal(members)
+	err = DecodeStrict(bytes.NewReader(rest), &patch.UpdateTaskRequest)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := h.Service.PatchTask(r.Context(), objectId, patch, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, task)
 	utils.ResponseJSON(w, http.StatusOK, "Task updated", task)
 	return nil
 }
@@ -125,7 +578,14 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) error {
 		return utils.BadRequest("Invalid task id", nil)
 	}
 
-	err = h.Service.DeleteTask(r.Context(), objectId)
+	cascade := r.URL.Query().Get("cascade") == "true"
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	err = h.Service.DeleteTask(r.Context(), objectId, cascade, ifMatch)
 	if err != nil {
 		return err
 	}
@@ -133,3 +593,33 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) error {
 	utils.ResponseJSON(w, http.StatusOK, "Task Deleted", nil)
 	return nil
 }
+
+// setETag exposes the task version as a strong entity tag.
+func setETag(w http.ResponseWriter, task *models.Task) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(task.Version)))
+}
+
+// parseIfMatch returns the task versions listed in the If-Match header, or
+// nil when the header is absent or "*". Weak or malformed tags never match,
+// so a header with none left fails the precondition.
+func parseIfMatch(r *http.Request) ([]int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		unquoted, err := strconv.Unquote(strings.TrimSpace(tag))
+		if err != nil {
+			continue
+		}
+		if v, err := strconv.Atoi(unquoted); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, utils.PreconditionFailed("If-Match does not match any task version", nil)
+	}
+	return versions, nil
+}
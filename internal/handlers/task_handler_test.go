@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []int
+		fails  bool
+	}{
+		{header: "", want: nil},
+		{header: "*", want: nil},
+		{header: ` * `, want: nil},
+		{header: `"3"`, want: []int{3}},
+		{header: `"3", "5"`, want: []int{3, 5}},
+		{header: `W/"3", "4"`, want: []int{4}},
+		{header: `"x", "7"`, want: []int{7}},
+		{header: `W/"3"`, fails: true},
+		{header: `3`, fails: true},
+		{header: `"three"`, fails: true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/api/tasks/1", nil)
+		if tt.header != "" {
+			r.Header.Set("If-Match", tt.header)
+		}
+		got, err := parseIfMatch(r)
+		if tt.fails {
+			var appErr *utils.AppError
+			if !errors.As(err, &appErr) || appErr.Code != http.StatusPreconditionFailed {
+				t.Errorf("If-Match %s: %v, %v", tt.header, got, err)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("If-Match %s = %v, %v, want %v", tt.header, got, err, tt.want)
+		}
+	}
+}
+
+func TestSetETag(t *testing.T) {
+	w := httptest.NewRecorder()
+	setETag(w, &models.Task{Version: 12})
+	if got := w.Header().Get("ETag"); got != `"12"` {
+		t.Errorf("ETag %s", got)
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"task-manager/internal/models"
+	"task-manager/internal/transfer"
+	"task-manager/internal/utils"
+)
+
+// imports larger than this are rejected while reading
+const maxImportBytes = 5 << 20
+
+// the media type and file name of each format. Imports without a format
+// parameter are recognised by their Content-Type.
+var fileFormats = map[string]struct {
+	mediaType string
+	filename  string
+}{
+	transfer.FormatCSV:      {"text/csv", "tasks.csv"},
+	transfer.FormatJSON:     {"application/json", "tasks.json"},
+	transfer.FormatICS:      {"text/calendar", "tasks.ics"},
+	transfer.FormatTodoTxt:  {"text/plain", "todo.txt"},
+	transfer.FormatMarkdown: {"text/markdown", "tasks.md"},
+}
+
+var errUnknownFormat = utils.BadRequest("format must be one of: "+strings.Join(transfer.Formats, ", "), nil)
+
+// stream every task matching the GetTasks filters as a file in ?format
+func (h *TaskHandler) ExportTasks(w http.ResponseWriter, r *http.Request) error {
+	format := r.URL.Query().Get("format")
+	enc := transfer.NewEncoder(format, w)
+	if enc == nil {
+		return errUnknownFormat
+	}
+
+	filters := taskFilters(r)
+	// a checklist has a heading per category, so keep each one together
+	if format == transfer.FormatMarkdown && filters["sort"] == "" {
+		filters["sort"] = "category"
+	}
+
+	contentType := fileFormats[format].mediaType
+	if format != transfer.FormatJSON {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+fileFormats[format].filename+`"`)
+	return streamTasks(w, contentType, enc, func(emit func([]models.Task) error) error {
+		return h.Service.ExportTasks(r.Context(), filters, emit)
+	})
+}
+
+// streamTasks writes the pages export hands to emit through enc. Nothing
+// is written until the first page, so an error before it still gets an
+// error response.
+func streamTasks(w http.ResponseWriter, contentType string, enc transfer.Encoder, export func(emit func([]models.Task) error) error) error {
+	started := false
+	err := export(func(tasks []models.Task) error {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := enc.Encode(tasks); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			w.Header().Del("Content-Disposition")
+			return err
+		}
+		// too late for an error response, the client sees a truncated file
+		log.Println("Error exporting tasks:", err)
+		return nil
+	}
+	return enc.Close()
+}
+
+// create tasks from a file in any export format, or only check them with ?dry_run=true
+func (h *TaskHandler) ImportTasks(w http.ResponseWriter, r *http.Request) error {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		for name, f := range fileFormats {
+			if f.mediaType == mediaType {
+				format = name
+			}
+		}
+	}
+	if _, ok := fileFormats[format]; !ok {
+		return errUnknownFormat
+	}
+
+	rows, err := transfer.Decode(format, http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		return utils.BadRequest("Invalid "+format+" file: "+err.Error(), nil)
+	}
+
+	report, err := h.Service.ImportTasks(r.Context(), rows, r.URL.Query().Get("dry_run") == "true")
+	if err != nil {
+		return err
+	}
+
+	message := "Tasks imported"
+	if report.DryRun {
+		message = "Import checked, nothing was created"
+	}
+	utils.ResponseJSON(w, http.StatusOK, message, report)
+	return nil
+}
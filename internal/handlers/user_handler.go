@@ -151,3 +151,36 @@ func (h *UserHandler) ResendVerificationEmail(w http.ResponseWriter, r *http.Req
 	utils.ResponseJSON(w, http.StatusOK, "Verification email sent to your email", nil)
 	return nil
 }
+
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) error {
+	user, err := h.Service.GetProfile(r.Context())
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Profile", user)
+	return nil
+}
+
+func (h *UserHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) error {
+	var req models.UpdateSettingsRequest
+
+	err := DecodeStrict(r.Body, &req)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(req)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	user, err := h.Service.UpdateSettings(r.Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Settings updated", user)
+	return nil
+}
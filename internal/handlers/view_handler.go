@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+	"task-manager/internal/utils"
+	"task-manager/internal/validation"
+)
+
+type ViewHandler struct {
+	Service *services.ViewService
+}
+
+func NewViewHandler(s *services.ViewService) *ViewHandler {
+	return &ViewHandler{Service: s}
+}
+
+func (h *ViewHandler) GetViews(w http.ResponseWriter, r *http.Request) error {
+	views, err := h.Service.GetViews(r.Context())
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Views", struct {
+		Count int                `json:"count"`
+		Views []models.SavedView `json:"views"`
+	}{
+		Count: len(views),
+		Views: views,
+	})
+	return nil
+}
+
+// views are addressed by id, or by key for the built-in ones
+func (h *ViewHandler) GetView(w http.ResponseWriter, r *http.Request) error {
+	view, err := h.Service.GetView(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "View", view)
+	return nil
+}
+
+func (h *ViewHandler) CreateView(w http.ResponseWriter, r *http.Request) error {
+	var body models.SavedViewRequest
+	err := DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	view, err := h.Service.CreateView(r.Context(), &body)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusCreated, "View created", view)
+	return nil
+}
+
+func (h *ViewHandler) UpdateView(w http.ResponseWriter, r *http.Request) error {
+	var body models.SavedViewRequest
+	err := DecodeStrict(r.Body, &body)
+	if err != nil {
+		return utils.BadRequest("Invalid JSON", nil)
+	}
+
+	err = validation.Validate.Struct(body)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	view, err := h.Service.UpdateView(r.Context(), r.PathValue("id"), &body)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "View updated", view)
+	return nil
+}
+
+func (h *ViewHandler) DeleteView(w http.ResponseWriter, r *http.Request) error {
+	err := h.Service.DeleteView(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "View deleted", nil)
+	return nil
+}
+
+// run a view, paginated like GET /api/tasks
+func (h *ViewHandler) GetViewTasks(w http.ResponseWriter, r *http.Request) error {
+	paging := map[string]string{}
+	for _, key := range []string{"limit", "page", "cursor", "total"} {
+		paging[key] = r.URL.Query().Get(key)
+	}
+
+	page, err := h.Service.GetViewTasks(r.Context(), r.PathValue("id"), paging)
+	if err != nil {
+		return err
+	}
+
+	utils.ResponseJSON(w, http.StatusOK, "Tasks", page)
+	return nil
+}
@@ -20,6 +20,8 @@ var publicRoutes = map[string]bool{
 	"/api/auth/reset-password":      true,
 	"/api/auth/verify-email":        true,
 	"/api/auth/resend-verification": true,
+	// calendar apps authenticate with the feed's own token
+	"/api/calendar/feed.ics": true,
 }
 
 func JWTMiddleware(next http.Handler) http.Handler {
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Comment struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	TaskID     primitive.ObjectID `bson:"task_id" json:"task_id"`
+	AuthorID   primitive.ObjectID `bson:"author_id" json:"author_id"`
+	AuthorName string             `bson:"author_name" json:"author_name"`
+	Body       string             `bson:"body" json:"body"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	EditedAt   *time.Time         `bson:"edited_at,omitempty" json:"edited_at,omitempty"`
+}
+
+type CommentRequest struct {
+	Body string `json:"body" validate:"required,min=1,max=5000"`
+}
+
+// CommentCount is the number of comments on one task.
+type CommentCount struct {
+	TaskID primitive.ObjectID `bson:"_id"`
+	Count  int                `bson:"count"`
+}
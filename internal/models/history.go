@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// history actions
+const (
+	ActionCreated  = "created"
+	ActionUpdated  = "updated"
+	ActionDeleted  = "deleted"
+	ActionRestored = "restored"
+)
+
+// HistoryEntry records one change to a task. Entries are append-only.
+type HistoryEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	TaskID    primitive.ObjectID `bson:"task_id" json:"task_id"`
+	ActorID   primitive.ObjectID `bson:"actor_id" json:"actor_id"`
+	ActorName string             `bson:"actor_name" json:"actor_name"`
+	Action    string             `bson:"action" json:"action"`
+	Changes   []FieldChange      `bson:"changes" json:"changes"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// FieldChange holds the JSON values of a field before and after a change.
+// Before is nil for created tasks and After is nil for deleted ones.
+type FieldChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before any    `bson:"before" json:"before"`
+	After  any    `bson:"after" json:"after"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// notification kinds
+const (
+	NotificationReminder = "reminder"
+	NotificationDigest   = "digest"
+)
+
+// Notification records that an email was sent. Its ID names what the email
+// was about, so that whoever stores a notification first is the one that
+// sends it, and it is never sent twice.
+type Notification struct {
+	ID     string              `bson:"_id"`
+	Kind   string              `bson:"kind"`
+	UserID primitive.ObjectID  `bson:"user_id"`
+	TaskID *primitive.ObjectID `bson:"task_id,omitempty"`
+	SentAt time.Time           `bson:"sent_at"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Project struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
+	OwnerID   primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Name      string             `bson:"name" json:"name"`
+	Color     string             `bson:"color" json:"color"`
+	Archived  bool               `bson:"archived" json:"archived"`
+	Inbox     bool               `bson:"inbox" json:"inbox"`
+	Members   []ProjectMember    `bson:"members,omitempty" json:"members,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	Version   int                `bson:"version" json:"version"`
+}
+
+// project roles, in increasing order of access
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+var roleRank = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// RoleAtLeast reports whether role grants at least the access of need.
+func RoleAtLeast(role, need string) bool {
+	return roleRank[role] >= roleRank[need]
+}
+
+type ProjectMember struct {
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Email     string             `bson:"email" json:"email"`
+	Role      string             `bson:"role" json:"role"`
+	InvitedAt time.Time          `bson:"invited_at" json:"invited_at"`
+}
+
+// RoleOf returns the role userID holds on the project, or "" for none.
+func (p *Project) RoleOf(userID primitive.ObjectID) string {
+	if p.OwnerID == userID {
+		return RoleOwner
+	}
+	for _, m := range p.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+type CreateProjectRequest struct {
+	Name  string `json:"name" validate:"required,min=1,max=100"`
+	Color string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+type UpdateProjectRequest struct {
+	Name     *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Color    *string `json:"color" validate:"omitempty,hexcolor"`
+	Archived *bool   `json:"archived"`
+}
+
+func (u UpdateProjectRequest) HasUpdates() bool {
+	return u.Name != nil ||
+		u.Color != nil ||
+		u.Archived != nil
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor owner"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=viewer editor owner"`
+}
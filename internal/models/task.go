@@ -7,40 +7,152 @@ import (
 )
 
 type Task struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id"`
-	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Title       string             `bson:"title" json:"title"`
-	Description string             `bson:"description" json:"description"`
-	Category    string             `bson:"category" json:"category"`
-	Priority    int                `bson:"priority" json:"priority"`
-	Status      string             `bson:"status" json:"status"`
-	DueDate     time.Time          `bson:"due_date,omitempty" json:"due_date"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"_id"`
+	UserID      primitive.ObjectID   `bson:"user_id" json:"user_id"`
+	ParentID    *primitive.ObjectID  `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	ProjectID   *primitive.ObjectID  `bson:"project_id,omitempty" json:"project_id,omitempty"`
+	BlockedBy   []primitive.ObjectID `bson:"blocked_by,omitempty" json:"blocked_by,omitempty"`
+	AssigneeIDs []primitive.ObjectID `bson:"assignee_ids,omitempty" json:"assignee_ids,omitempty"`
+	ExternalID  string               `bson:"external_id,omitempty" json:"external_id,omitempty"`
+	Title       string               `bson:"title" json:"title"`
+	Description string               `bson:"description" json:"description"`
+	Category    string               `bson:"category" json:"category"`
+	Tags        []string             `bson:"tags,omitempty" json:"tags,omitempty"`
+	Priority    int                  `bson:"priority" json:"priority"`
+	Status      string               `bson:"status" json:"status"`
+	DueDate     time.Time            `bson:"due_date,omitempty" json:"due_date"`
+	Recurrence  string               `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	Occurrence  int                  `bson:"occurrence,omitempty" json:"occurrence,omitempty"`
+	// NextOccurrenceID is the occurrence created when this one was first
+	// completed; completing it again creates no other.
+	NextOccurrenceID *primitive.ObjectID `bson:"next_occurrence_id,omitempty" json:"next_occurrence_id,omitempty"`
+	CreatedAt        time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time           `bson:"updated_at" json:"updated_at"`
+	DeletedAt        *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	CompletedAt      *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Version          int                 `bson:"version" json:"version"`
+
+	// computed when tasks are read, never stored
+	Progress     *TaskProgress     `bson:"-" json:"progress,omitempty"`
+	Subtasks     []Task            `bson:"-" json:"subtasks,omitempty"`
+	CommentCount int               `bson:"-" json:"comment_count"`
+	Score        float64           `bson:"-" json:"score,omitempty"`
+	Highlights   map[string]string `bson:"-" json:"highlights,omitempty"`
+}
+
+// TaskPage is one page of a task listing. Total is only filled in when the
+// caller asks for it, and the cursors are empty at either end of the list.
+type TaskPage struct {
+	Count      int    `json:"count"`
+	Total      *int64 `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Tasks      []Task `json:"tasks"`
+}
+
+// TaskProgress rolls up the completion state of a task's direct children.
+type TaskProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
 }
 
 type CreateTaskRequest struct {
-	Title       string `json:"title" validate:"required,min=3"`
-	Description string `json:"description" validate:"required"`
-	Category    string `json:"category" validate:"required"`
-	Priority    int    `json:"priority" validate:"gte=1,lte=5"`
-	Status      string `json:"status" validate:"required,oneof=pending completed in_progress"`
-	DueDate     string `json:"due_date" validate:"omitempty,rfc3339"`
+	ExternalID  string   `json:"external_id" validate:"omitempty,max=200"`
+	Title       string   `json:"title" validate:"required,min=3"`
+	Description string   `json:"description" validate:"required"`
+	Category    string   `json:"category" validate:"required"`
+	Tags        []string `json:"tags" validate:"omitempty,dive,min=1,max=32"`
+	Priority    int      `json:"priority" validate:"gte=1,lte=5"`
+	Status      string   `json:"status" validate:"required,oneof=pending completed in_progress"`
+	DueDate     string   `json:"due_date" validate:"omitempty,rfc3339"`
+	Recurrence  string   `json:"recurrence" validate:"omitempty,rrule"`
+	ProjectID   string   `json:"project_id" validate:"omitempty,mongodb"`
 }
 
 type UpdateTaskRequest struct {
-	Title       *string `json:"title" validate:"omitempty,min=3"`
-	Description *string `json:"description" validate:"omitempty"`
-	Category    *string `json:"category" validate:"omitempty"`
-	Status      *string `json:"status" validate:"omitempty,oneof=pending in_progress completed"`
-	Priority    *int    `json:"priority" validate:"omitempty,gte=1,lte=5"`
-	DueDate     *string `json:"due_date" validate:"omitempty,rfc3339"`
+	Title       *string   `json:"title" validate:"omitempty,min=3"`
+	Description *string   `json:"description" validate:"omitempty"`
+	Category    *string   `json:"category" validate:"omitempty"`
+	Tags        *[]string `json:"tags" validate:"omitempty,dive,min=1,max=32"`
+	Status      *string   `json:"status" validate:"omitempty,oneof=pending in_progress completed"`
+	Priority    *int      `json:"priority" validate:"omitempty,gte=1,lte=5"`
+	DueDate     *string   `json:"due_date" validate:"omitempty,rfc3339"`
+	Recurrence  *string   `json:"recurrence" validate:"omitempty,rrule"`
+	ProjectID   *string   `json:"project_id" validate:"omitempty,mongodb"`
+}
+
+type TagsRequest struct {
+	Tags []string `json:"tags" validate:"required,min=1,dive,min=1,max=32"`
+}
+
+type TagCount struct {
+	Tag   string `bson:"_id" json:"tag"`
+	Count int    `bson:"count" json:"count"`
+}
+
+type AddDependencyRequest struct {
+	BlockerID string `json:"blocker_id" validate:"required"`
+}
+
+type AssignTaskRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ReassignTaskRequest replaces every assignee at once; an empty list
+// unassigns everyone.
+type ReassignTaskRequest struct {
+	Emails []string `json:"emails" validate:"max=20,dive,required,email"`
+}
+
+// TaskPatch is a JSON Merge Patch (RFC 7396) against a task: the non-null
+// members are set like an update and the null ones are listed in Clear.
+type TaskPatch struct {
+	UpdateTaskRequest
+	Clear []string
+}
+
+// ClearableTaskFields are the fields a merge patch may set to null. The rest
+// are required on every task.
+var ClearableTaskFields = []string{"description", "category", "tags", "due_date", "recurrence", "project_id"}
+
+// bulk actions
+const (
+	BulkUpdate      = "update"
+	BulkStatus      = "status"
+	BulkAddTag      = "add_tag"
+	BulkDelete      = "delete"
+	BulkMoveProject = "move_project"
+)
+
+// BulkTaskRequest applies one action to many tasks. Which of the remaining
+// fields is read depends on Action.
+type BulkTaskRequest struct {
+	IDs       []string           `json:"ids" validate:"required,min=1,max=100"`
+	Action    string             `json:"action" validate:"required,oneof=update status add_tag delete move_project"`
+	Update    *UpdateTaskRequest `json:"update"`
+	Status    string             `json:"status" validate:"omitempty,oneof=pending in_progress completed"`
+	Tag       string             `json:"tag" validate:"omitempty,max=32"`
+	ProjectID *string            `json:"project_id" validate:"omitempty,mongodb"`
+	Cascade   bool               `json:"cascade"`
+}
+
+// BulkResult reports the outcome of a bulk action for one task.
+type BulkResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Code    int    `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Errors  any    `json:"errors,omitempty"`
 }
 
 func (u UpdateTaskRequest) HasUpdates() bool {
 	return u.Title != nil ||
 		u.Description != nil ||
+		u.Category != nil ||
 		u.Status != nil ||
 		u.Priority != nil ||
-		u.DueDate != nil
+		u.DueDate != nil ||
+		u.Tags != nil ||
+		u.Recurrence != nil ||
+		u.ProjectID != nil
 }
@@ -0,0 +1,32 @@
+package models
+
+// import row outcomes
+const (
+	ImportCreated   = "created"
+	ImportValid     = "valid"
+	ImportDuplicate = "duplicate"
+	ImportInvalid   = "invalid"
+)
+
+// ImportRowResult reports what happened to one row of an import. Row is the
+// line or array element it came from.
+type ImportRowResult struct {
+	Row        int    `json:"row"`
+	ExternalID string `json:"external_id,omitempty"`
+	Status     string `json:"status"`
+	ID         string `json:"id,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Errors     any    `json:"errors,omitempty"`
+}
+
+// ImportReport summarises an import. In a dry run nothing is created and
+// rows that would have been are reported as valid.
+type ImportReport struct {
+	DryRun     bool              `json:"dry_run"`
+	Total      int               `json:"total"`
+	Created    int               `json:"created"`
+	Valid      int               `json:"valid"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Rows       []ImportRowResult `json:"rows"`
+}
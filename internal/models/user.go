@@ -19,6 +19,91 @@ type User struct {
 	VerificationTokenExpiresAt  *time.Time         `bson:"verification_token_expires_at,omitempty"`
 	PasswordResetToken          string             `bson:"password_reset_token,omitempty"`
 	PasswordResetTokenExpiresAt *time.Time         `bson:"password_reset_token_expires_at,omitempty"`
+	CalendarToken               string             `bson:"calendar_token,omitempty"`
+	Settings                    UserSettings       `bson:"settings"`
+}
+
+// DefaultReminderWindow is how long before a task is due its reminder goes
+// out, for users who have not chosen.
+const DefaultReminderWindow = 24 * time.Hour
+
+// digest frequencies
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// digest defaults for users who have not chosen
+const (
+	DefaultDigestTime    = "08:00"
+	DefaultDigestWeekday = "monday"
+)
+
+// UserSettings are the preferences a user sets on their profile.
+type UserSettings struct {
+	// ReminderWindowMinutes is how long before a task is due to remind its
+	// owner and assignees. Nil means DefaultReminderWindow and 0 turns
+	// reminders off.
+	ReminderWindowMinutes *int `bson:"reminder_window_minutes,omitempty" json:"reminder_window_minutes"`
+
+	// DigestFrequency is off (also when empty), daily or weekly. Digests go
+	// out at DigestTime, "15:04", in Timezone, and weekly ones on
+	// DigestWeekday.
+	DigestFrequency string `bson:"digest_frequency,omitempty" json:"digest_frequency"`
+	DigestTime      string `bson:"digest_time,omitempty" json:"digest_time"`
+	DigestWeekday   string `bson:"digest_weekday,omitempty" json:"digest_weekday"`
+	Timezone        string `bson:"timezone,omitempty" json:"timezone"`
+}
+
+// ReminderWindow returns the user's reminder window, 0 when they have
+// turned reminders off.
+func (s UserSettings) ReminderWindow() time.Duration {
+	if s.ReminderWindowMinutes == nil {
+		return DefaultReminderWindow
+	}
+	return time.Duration(*s.ReminderWindowMinutes) * time.Minute
+}
+
+// WithDefaults returns s with every setting the user has not chosen filled
+// in.
+func (s UserSettings) WithDefaults() UserSettings {
+	window := int(s.ReminderWindow() / time.Minute)
+	s.ReminderWindowMinutes = &window
+	if s.DigestFrequency == "" {
+		s.DigestFrequency = DigestOff
+	}
+	if s.DigestTime == "" {
+		s.DigestTime = DefaultDigestTime
+	}
+	if s.DigestWeekday == "" {
+		s.DigestWeekday = DefaultDigestWeekday
+	}
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+	return s
+}
+
+// Location is the user's time zone, UTC when it is unset or unknown.
+func (s UserSettings) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// UpdateSettingsRequest changes the settings it names and leaves the rest.
+type UpdateSettingsRequest struct {
+	ReminderWindowMinutes *int    `json:"reminder_window_minutes" validate:"omitempty,gte=0,lte=43200"`
+	DigestFrequency       *string `json:"digest_frequency" validate:"omitempty,oneof=off daily weekly"`
+	DigestTime            *string `json:"digest_time" validate:"omitempty,datetime=15:04"`
+	DigestWeekday         *string `json:"digest_weekday" validate:"omitempty,oneof=sunday monday tuesday wednesday thursday friday saturday"`
+	Timezone              *string `json:"timezone" validate:"omitempty,timezone"`
 }
 
 type CreateUserRequest struct {
@@ -33,6 +118,7 @@ type UserResponse struct {
 	Email     string             `json:"email"`
 	CreatedAt time.Time          `json:"created_at"`
 	UpdatedAt time.Time          `json:"updated_at"`
+	Settings  *UserSettings      `json:"settings,omitempty"`
 }
 
 type Credentials struct {
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedView is a named task listing: filters as accepted by GET /api/tasks
+// plus a sort. Built-in views are not stored; they have a Key instead of an
+// ID and belong to nobody.
+type SavedView struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitzero"`
+	Key       string             `bson:"-" json:"key,omitempty"`
+	OwnerID   primitive.ObjectID `bson:"owner_id" json:"owner_id,omitzero"`
+	Name      string             `bson:"name" json:"name"`
+	Filters   map[string]string  `bson:"filters" json:"filters"`
+	Sort      string             `bson:"sort,omitempty" json:"sort,omitempty"`
+	Order     string             `bson:"order,omitempty" json:"order,omitempty"`
+	BuiltIn   bool               `bson:"-" json:"built_in"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at,omitzero"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at,omitzero"`
+}
+
+type SavedViewRequest struct {
+	Name    string            `json:"name" validate:"required,min=1,max=100"`
+	Filters map[string]string `json:"filters" validate:"max=20"`
+	Sort    string            `json:"sort" validate:"omitempty,max=100"`
+	Order   string            `json:"order" validate:"omitempty,oneof=asc desc"`
+}
+
+// ViewFilters are the GET /api/tasks parameters a view may store. Paging
+// parameters are given when the view is opened.
+var ViewFilters = []string{"category", "status", "search", "q", "tag", "tag_mode", "project", "assigned", "blocked", "view"}
@@ -0,0 +1,287 @@
+package recurrence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule is the subset of an RFC 5545 RRULE that tasks support:
+// FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, BYDAY, COUNT and UNTIL.
+type Rule struct {
+	Freq     string
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    time.Time
+}
+
+// WeekdayNum is a BYDAY entry. N is the ordinal within the month for
+// MONTHLY rules (e.g. 2 for "2TU", -1 for "-1FR") and 0 for every such day.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE". An
+// optional "RRULE:" prefix is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		key = strings.ToUpper(key)
+		if seen[key] {
+			return nil, fmt.Errorf("%s given more than once", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+			switch rule.Freq {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive integer")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive integer")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(strings.ToUpper(d))
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot be combined")
+	}
+	if rule.Freq == "YEARLY" && len(rule.ByDay) > 0 {
+		return nil, fmt.Errorf("BYDAY is not supported with FREQ=YEARLY")
+	}
+	for _, d := range rule.ByDay {
+		if d.N != 0 && rule.Freq != "MONTHLY" {
+			return nil, fmt.Errorf("ordinal BYDAY values are only supported with FREQ=MONTHLY")
+		}
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// a date-only UNTIL includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	wd, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+
+	n := 0
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+		}
+	}
+	return WeekdayNum{N: n, Weekday: wd}, nil
+}
+
+// Next returns the occurrence following prev, which is the occurrence-th
+// occurrence of the series (starting at 1). It reports false once the rule
+// is exhausted by COUNT or UNTIL.
+func (r *Rule) Next(prev time.Time, occurrence int) (time.Time, bool) {
+	if r.Count > 0 && occurrence >= r.Count {
+		return time.Time{}, false
+	}
+
+	var next time.Time
+	switch r.Freq {
+	case "DAILY":
+		next = r.nextDaily(prev)
+	case "WEEKLY":
+		next = r.nextWeekly(prev)
+	case "MONTHLY":
+		next = r.nextMonthly(prev)
+	case "YEARLY":
+		next = r.nextYearly(prev)
+	}
+
+	if next.IsZero() || (!r.Until.IsZero() && next.After(r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r *Rule) matchesDay(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) nextDaily(prev time.Time) time.Time {
+	// the weekdays repeat after 7 steps, so a BYDAY that no step lands on,
+	// such as INTERVAL=7 with another weekday, never matches
+	next := prev
+	for range 7 {
+		next = next.AddDate(0, 0, r.Interval)
+		if r.matchesDay(next) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (r *Rule) nextWeekly(prev time.Time) time.Time {
+	if len(r.ByDay) == 0 {
+		return prev.AddDate(0, 0, 7*r.Interval)
+	}
+
+	// weeks start on Monday (WKST=MO)
+	offset := (int(prev.Weekday()) + 6) % 7
+	for d := offset + 1; d < 7; d++ {
+		candidate := prev.AddDate(0, 0, d-offset)
+		if r.matchesDay(candidate) {
+			return candidate
+		}
+	}
+
+	weekStart := prev.AddDate(0, 0, -offset+7*r.Interval)
+	for d := 0; d < 7; d++ {
+		candidate := weekStart.AddDate(0, 0, d)
+		if r.matchesDay(candidate) {
+			return candidate
+		}
+	}
+	return time.Time{}
+}
+
+func (r *Rule) nextMonthly(prev time.Time) time.Time {
+	if len(r.ByDay) == 0 {
+		// months without the anchor day are skipped, as RFC 5545 requires
+		for step := 1; step <= 12; step++ {
+			candidate := addMonths(prev, step*r.Interval, prev.Day())
+			if candidate.Day() == prev.Day() {
+				return candidate
+			}
+		}
+		return time.Time{}
+	}
+
+	for _, c := range r.monthDays(prev, 0) {
+		if c.After(prev) {
+			return c
+		}
+	}
+	for step := 1; step <= 12; step++ {
+		if days := r.monthDays(prev, step*r.Interval); len(days) > 0 {
+			return days[0]
+		}
+	}
+	return time.Time{}
+}
+
+// monthDays returns the BYDAY matches, in order, in the month that is
+// months after prev's month, keeping prev's time of day.
+func (r *Rule) monthDays(prev time.Time, months int) []time.Time {
+	first := addMonths(prev, months, 1)
+	daysIn := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	out := []time.Time{}
+	for day := 1; day <= daysIn; day++ {
+		candidate := first.AddDate(0, 0, day-1)
+		for _, d := range r.ByDay {
+			if d.Weekday != candidate.Weekday() {
+				continue
+			}
+			nth := (day-1)/7 + 1
+			nthFromEnd := -((daysIn-day)/7 + 1)
+			if d.N == 0 || d.N == nth || d.N == nthFromEnd {
+				out = append(out, candidate)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (r *Rule) nextYearly(prev time.Time) time.Time {
+	// Feb 29 only recurs in leap years
+	for step := 1; step <= 8; step++ {
+		candidate := addMonths(prev, 12*step*r.Interval, prev.Day())
+		if candidate.Day() == prev.Day() {
+			return candidate
+		}
+	}
+	return time.Time{}
+}
+
+// addMonths moves t by months and sets the day of month, letting time.Date
+// normalise days past the end of the month.
+func addMonths(t time.Time, months, day int) time.Time {
+	return time.Date(t.Year(), t.Month()+time.Month(months), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,91 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 9, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	rule, err := Parse("RRULE:FREQ=monthly;interval=2;byday=mo,-1fr;UNTIL=20260301")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Freq != "MONTHLY" || rule.Interval != 2 || len(rule.ByDay) != 2 {
+		t.Errorf("parsed %+v", rule)
+	}
+	if rule.ByDay[1] != (WeekdayNum{N: -1, Weekday: time.Friday}) {
+		t.Errorf("BYDAY %+v", rule.ByDay)
+	}
+	if want := time.Date(2026, 3, 1, 23, 59, 59, 0, time.UTC); !rule.Until.Equal(want) {
+		t.Errorf("UNTIL %v, want the end of the day", rule.Until)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"FREQ=HOURLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=-1FR",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		rule       string
+		prev       time.Time
+		occurrence int
+		want       time.Time
+	}{
+		{"FREQ=DAILY", day(2026, 1, 5), 1, day(2026, 1, 6)},
+		{"FREQ=DAILY;INTERVAL=3", day(2026, 1, 30), 1, day(2026, 2, 2)},
+		{"FREQ=DAILY;INTERVAL=2;BYDAY=MO,WE,FR", day(2026, 1, 5), 1, day(2026, 1, 7)},
+		{"FREQ=DAILY;INTERVAL=2;BYDAY=MO,WE,FR", day(2026, 1, 9), 1, day(2026, 1, 19)},
+		{"FREQ=DAILY;INTERVAL=7;BYDAY=TU", day(2026, 1, 5), 1, time.Time{}},
+		{"FREQ=WEEKLY", day(2026, 1, 5), 1, day(2026, 1, 12)},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", day(2026, 1, 5), 1, day(2026, 1, 8)},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", day(2026, 1, 8), 1, day(2026, 1, 12)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", day(2026, 1, 8), 1, day(2026, 1, 19)},
+		{"FREQ=WEEKLY;BYDAY=SU", day(2026, 1, 5), 1, day(2026, 1, 11)},
+		{"FREQ=MONTHLY", day(2026, 1, 15), 1, day(2026, 2, 15)},
+		{"FREQ=MONTHLY", day(2026, 1, 31), 1, day(2026, 3, 31)},
+		{"FREQ=MONTHLY;BYDAY=2TU", day(2026, 1, 13), 1, day(2026, 2, 10)},
+		{"FREQ=MONTHLY;BYDAY=-1FR", day(2026, 1, 30), 1, day(2026, 2, 27)},
+		{"FREQ=MONTHLY;BYDAY=1MO,3MO", day(2026, 1, 5), 1, day(2026, 1, 19)},
+		{"FREQ=YEARLY", day(2026, 3, 1), 1, day(2027, 3, 1)},
+		{"FREQ=YEARLY", day(2024, 2, 29), 1, day(2028, 2, 29)},
+		{"FREQ=DAILY;COUNT=3", day(2026, 1, 5), 2, day(2026, 1, 6)},
+		{"FREQ=DAILY;COUNT=3", day(2026, 1, 6), 3, time.Time{}},
+		{"FREQ=DAILY;UNTIL=20260107", day(2026, 1, 6), 1, day(2026, 1, 7)},
+		{"FREQ=DAILY;UNTIL=20260107", day(2026, 1, 7), 1, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.rule, err)
+		}
+		got, ok := rule.Next(tt.prev, tt.occurrence)
+		if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+			t.Errorf("%s after %s: got %s %v, want %s", tt.rule, tt.prev.Format(time.DateOnly), got.Format(time.DateOnly), ok, tt.want.Format(time.DateOnly))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type CommentRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewCommentRepository(client *mongo.Client, dbName string) *CommentRepository {
+	return &CommentRepository{
+		Collection: client.Database(dbName).Collection("comments"),
+	}
+}
+
+func (cr *CommentRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
+	comment.ID = primitive.NewObjectID()
+	comment.CreatedAt = time.Now()
+
+	_, err := cr.Collection.InsertOne(ctx, comment)
+	if err != nil {
+		return utils.Internal("Error creating comment", nil)
+	}
+	return nil
+}
+
+func (cr *CommentRepository) GetComments(ctx context.Context, taskID primitive.ObjectID) ([]models.Comment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := cr.Collection.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, utils.Internal("Error getting comments", nil)
+	}
+	defer cursor.Close(ctx)
+
+	comments := []models.Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, utils.Internal("Error getting comments", nil)
+	}
+	return comments, nil
+}
+
+func (cr *CommentRepository) GetCommentByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	var comment models.Comment
+	err := cr.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&comment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("Comment not found", nil)
+		}
+		return nil, utils.Internal("Error decoding comment", nil)
+	}
+	return &comment, nil
+}
+
+func (cr *CommentRepository) UpdateComment(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	now := time.Now()
+	comment.EditedAt = &now
+	update := bson.M{"$set": bson.M{"body": comment.Body, "edited_at": comment.EditedAt}}
+
+	_, err := cr.Collection.UpdateOne(ctx, bson.M{"_id": comment.ID}, update)
+	if err != nil {
+		return nil, utils.Internal("Error updating comment", nil)
+	}
+	return comment, nil
+}
+
+func (cr *CommentRepository) DeleteComment(ctx context.Context, id primitive.ObjectID) error {
+	_, err := cr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return utils.Internal("Error deleting comment", nil)
+	}
+	return nil
+}
+
+func (cr *CommentRepository) DeleteTaskComments(ctx context.Context, taskIDs []primitive.ObjectID) error {
+	_, err := cr.Collection.DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+	if err != nil {
+		return utils.Internal("Error deleting comments", nil)
+	}
+	return nil
+}
+
+func (cr *CommentRepository) CommentCounts(ctx context.Context, taskIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"task_id": bson.M{"$in": taskIDs}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$task_id", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := cr.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, utils.Internal("Error counting comments", nil)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []models.CommentCount
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, utils.Internal("Error counting comments", nil)
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, row := range rows {
+		counts[row.TaskID] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,595 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// documentCollection is a minimal document store used by the embedded
+// backends. It understands only the filters the repositories and services
+// in this module build: $and, $or and $nor, equality on a field, a dotted
+// path or an array element, and the $ne, $gt, $gte, $lt, $lte, $in, $nin,
+// $all and $size operators. Anything else is an error rather than a silent
+// mismatch. FindOne reports mongo.ErrNoDocuments when nothing matches, like
+// the real driver.
+type documentCollection interface {
+	InsertOne(ctx context.Context, doc any) error
+	Find(ctx context.Context, filter bson.M, sort bson.D, limit, skip int) ([]bson.Raw, error)
+	FindOne(ctx context.Context, filter bson.M) (bson.Raw, error)
+	UpdateOne(ctx context.Context, filter bson.M, update bson.M) (int64, error)
+	UpdateMany(ctx context.Context, filter bson.M, update bson.M) (int64, error)
+	DeleteOne(ctx context.Context, filter bson.M) (int64, error)
+	DeleteMany(ctx context.Context, filter bson.M) (int64, error)
+}
+
+// normalize converts v into the representation the bson decoder produces,
+// so values built in Go compare equal to values read back from storage.
+func normalize(v any) (any, error) {
+	b, err := bson.Marshal(bson.D{{Key: "v", Value: v}})
+	if err != nil {
+		return nil, err
+	}
+	var out bson.D
+	if err := bson.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out[0].Value, nil
+}
+
+func normalizeDoc(v any) (bson.D, error) {
+	n, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := n.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("expected document, got %T", n)
+	}
+	return d, nil
+}
+
+func decodeDoc(raw bson.Raw) (bson.D, error) {
+	var d bson.D
+	if err := bson.Unmarshal(raw, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// lookup resolves a dotted path. Paths that cross an array of documents
+// collect the value from every element, as MongoDB does for "members.user_id".
+func lookup(doc bson.D, path string) (any, bool) {
+	head, rest, nested := strings.Cut(path, ".")
+	for _, e := range doc {
+		if e.Key != head {
+			continue
+		}
+		if !nested {
+			return e.Value, true
+		}
+		switch sub := e.Value.(type) {
+		case bson.D:
+			return lookup(sub, rest)
+		case bson.A:
+			values := bson.A{}
+			for _, el := range sub {
+				if d, ok := el.(bson.D); ok {
+					if v, ok := lookup(d, rest); ok {
+						values = append(values, v)
+					}
+				}
+			}
+			return values, len(values) > 0
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// filterDocuments applies filter, sort, skip and limit to raw documents with
+// the semantics of a MongoDB Find.
+func filterDocuments(raws []bson.Raw, filter bson.M, sortSpec bson.D, limit, skip int) ([]bson.Raw, error) {
+	f, err := normalizeDoc(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	type entry struct {
+		raw bson.Raw
+		doc bson.D
+	}
+	matched := []entry{}
+	for _, raw := range raws {
+		doc, err := decodeDoc(raw)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := matchDocument(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, entry{raw: raw, doc: doc})
+		}
+	}
+
+	if len(sortSpec) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, key := range sortSpec {
+				a, _ := lookup(matched[i].doc, key.Key)
+				b, _ := lookup(matched[j].doc, key.Key)
+				c := compareOrder(a, b)
+				if c == 0 {
+					continue
+				}
+				if direction(key.Value) < 0 {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	if skip > 0 {
+		if skip >= len(matched) {
+			matched = matched[:0]
+		} else {
+			matched = matched[skip:]
+		}
+	}
+	if limit < 0 {
+		limit = -limit
+	}
+	if limit > 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+
+	out := make([]bson.Raw, len(matched))
+	for i, m := range matched {
+		out[i] = m.raw
+	}
+	return out, nil
+}
+
+func direction(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 1
+}
+
+func matchDocument(doc bson.D, filter bson.D) (bool, error) {
+	for _, e := range filter {
+		switch e.Key {
+		case "$and", "$or", "$nor":
+			clauses, ok := e.Value.(bson.A)
+			if !ok {
+				return false, fmt.Errorf("%s expects an array", e.Key)
+			}
+			anyMatch, all := false, true
+			for _, c := range clauses {
+				sub, ok := c.(bson.D)
+				if !ok {
+					return false, fmt.Errorf("%s expects documents", e.Key)
+				}
+				m, err := matchDocument(doc, sub)
+				if err != nil {
+					return false, err
+				}
+				anyMatch = anyMatch || m
+				all = all && m
+			}
+			if (e.Key == "$and" && !all) || (e.Key == "$or" && !anyMatch) || (e.Key == "$nor" && anyMatch) {
+				return false, nil
+			}
+		default:
+			if strings.HasPrefix(e.Key, "$") {
+				return false, fmt.Errorf("unsupported operator %s", e.Key)
+			}
+			value, exists := lookup(doc, e.Key)
+			m, err := matchField(value, exists, e.Value)
+			if err != nil || !m {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func isOperatorDoc(v any) (bson.D, bool) {
+	d, ok := v.(bson.D)
+	if !ok || len(d) == 0 || !strings.HasPrefix(d[0].Key, "$") {
+		return nil, false
+	}
+	return d, true
+}
+
+// matchField matches a stored value against either a plain value, compared
+// for equality, or a document of query operators.
+func matchField(value any, exists bool, cond any) (bool, error) {
+	ops, ok := isOperatorDoc(cond)
+	if !ok {
+		if err := checkValue(cond); err != nil {
+			return false, err
+		}
+		return valueEquals(value, exists, cond), nil
+	}
+
+	for _, op := range ops {
+		var m bool
+		switch op.Key {
+		case "$ne":
+			if err := checkValue(op.Value); err != nil {
+				return false, err
+			}
+			m = !valueEquals(value, exists, op.Value)
+		case "$gt", "$gte", "$lt", "$lte":
+			if err := checkValue(op.Value); err != nil {
+				return false, err
+			}
+			m = matchCompare(value, exists, op.Key, op.Value)
+		case "$in", "$nin", "$all":
+			list, ok := op.Value.(bson.A)
+			if !ok {
+				return false, fmt.Errorf("%s expects an array", op.Key)
+			}
+			in, all := false, exists && len(list) > 0
+			for _, candidate := range list {
+				if err := checkValue(candidate); err != nil {
+					return false, err
+				}
+				eq := valueEquals(value, exists, candidate)
+				in = in || eq
+				all = all && eq
+			}
+			switch op.Key {
+			case "$in":
+				m = in
+			case "$nin":
+				m = !in
+			default:
+				m = all
+			}
+		case "$size":
+			n, ok := toFloat(op.Value)
+			if !ok {
+				return false, fmt.Errorf("$size expects a number")
+			}
+			arr, isArray := value.(bson.A)
+			m = isArray && float64(len(arr)) == n
+		default:
+			return false, fmt.Errorf("unsupported operator %s", op.Key)
+		}
+		if !m {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// checkValue rejects values a condition may not compare against: nested
+// operators and types the repositories never store.
+func checkValue(v any) error {
+	if _, ok := isOperatorDoc(v); ok {
+		return fmt.Errorf("unsupported nested operator %s", v.(bson.D)[0].Key)
+	}
+	if typeClass(v) == 0 {
+		return fmt.Errorf("unsupported value of type %T", v)
+	}
+	return nil
+}
+
+func matchCompare(value any, exists bool, op string, cond any) bool {
+	if !exists {
+		return false
+	}
+	if arr, ok := value.(bson.A); ok {
+		for _, el := range arr {
+			if matchCompare(el, true, op, cond) {
+				return true
+			}
+		}
+		return false
+	}
+	if typeClass(value) != typeClass(cond) {
+		return false
+	}
+	c := compareValues(value, cond)
+	switch op {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	case "$lte":
+		return c <= 0
+	}
+	return false
+}
+
+// valueEquals reports whether a stored value satisfies an equality condition.
+// Arrays match when any element equals the condition, as in MongoDB.
+func valueEquals(value any, exists bool, cond any) bool {
+	if typeClass(cond) == 1 {
+		return !exists || typeClass(value) == 1
+	}
+	if !exists {
+		return false
+	}
+	if arr, ok := value.(bson.A); ok {
+		if _, condIsArray := cond.(bson.A); !condIsArray {
+			for _, el := range arr {
+				if typeClass(el) == typeClass(cond) && compareValues(el, cond) == 0 {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return typeClass(value) == typeClass(cond) && compareValues(value, cond) == 0
+}
+
+// typeClass ranks bson types following MongoDB's comparison order. Types the
+// repositories never store are class 0.
+func typeClass(v any) int {
+	switch v.(type) {
+	case nil, bson.Null, bson.Undefined:
+		return 1
+	case int32, int64, float64, int:
+		return 2
+	case string:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case bson.Binary:
+		return 6
+	case bson.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case bson.DateTime:
+		return 9
+	}
+	return 0
+}
+
+// compareOrder compares values of possibly different types for sorting.
+func compareOrder(a, b any) int {
+	ca, cb := typeClass(a), typeClass(b)
+	if ca != cb {
+		if ca < cb {
+			return -1
+		}
+		return 1
+	}
+	return compareValues(a, b)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func cmp[T int64 | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareValues compares two values of the same type class.
+func compareValues(a, b any) int {
+	if fa, ok := toFloat(a); ok {
+		fb, _ := toFloat(b)
+		return cmp(fa, fb)
+	}
+	switch x := a.(type) {
+	case nil, bson.Null, bson.Undefined:
+		return 0
+	case string:
+		y, _ := b.(string)
+		return cmp(x, y)
+	case bool:
+		y, _ := b.(bool)
+		if x == y {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case bson.DateTime:
+		y, _ := b.(bson.DateTime)
+		return cmp(int64(x), int64(y))
+	case bson.Binary:
+		y, _ := b.(bson.Binary)
+		return bytes.Compare(x.Data, y.Data)
+	case bson.ObjectID:
+		y, _ := b.(bson.ObjectID)
+		return bytes.Compare(x[:], y[:])
+	case bson.A:
+		y, _ := b.(bson.A)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compareOrder(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return cmp(int64(len(x)), int64(len(y)))
+	case bson.D:
+		y, _ := b.(bson.D)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := cmp(x[i].Key, y[i].Key); c != 0 {
+				return c
+			}
+			if c := compareOrder(x[i].Value, y[i].Value); c != 0 {
+				return c
+			}
+		}
+		return cmp(int64(len(x)), int64(len(y)))
+	}
+	return cmp(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// applyUpdate applies an update document to raw and returns the re-encoded
+// result. It supports $set, $unset, $inc, $addToSet, with or without $each,
+// and $pull of a single value.
+func applyUpdate(raw bson.Raw, update bson.M) (bson.Raw, error) {
+	doc, err := decodeDoc(raw)
+	if err != nil {
+		return nil, err
+	}
+	u, err := normalizeDoc(update)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range u {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("%s expects a document", op.Key)
+		}
+		for _, f := range fields {
+			current, exists := lookup(doc, f.Key)
+			switch op.Key {
+			case "$set":
+				doc = setPath(doc, f.Key, f.Value)
+			case "$unset":
+				doc = unsetPath(doc, f.Key)
+			case "$inc":
+				base := any(int32(0))
+				if exists {
+					base = current
+				}
+				if typeClass(base) != 2 || typeClass(f.Value) != 2 {
+					return nil, fmt.Errorf("$inc expects numbers")
+				}
+				doc = setPath(doc, f.Key, addNumbers(base, f.Value))
+			case "$addToSet":
+				items := bson.A{f.Value}
+				if each, ok := isOperatorDoc(f.Value); ok {
+					if each[0].Key != "$each" {
+						return nil, fmt.Errorf("unsupported operator %s in $addToSet", each[0].Key)
+					}
+					items, ok = each[0].Value.(bson.A)
+					if !ok {
+						return nil, fmt.Errorf("$each expects an array")
+					}
+				}
+				arr, _ := current.(bson.A)
+				next := append(bson.A{}, arr...)
+				for _, item := range items {
+					if !valueEquals(next, true, item) {
+						next = append(next, item)
+					}
+				}
+				doc = setPath(doc, f.Key, next)
+			case "$pull":
+				if err := checkValue(f.Value); err != nil {
+					return nil, err
+				}
+				arr, ok := current.(bson.A)
+				if !ok {
+					continue
+				}
+				next := bson.A{}
+				for _, item := range arr {
+					if !valueEquals(item, true, f.Value) {
+						next = append(next, item)
+					}
+				}
+				doc = setPath(doc, f.Key, next)
+			default:
+				return nil, fmt.Errorf("unsupported update operator %s", op.Key)
+			}
+		}
+	}
+
+	return bson.Marshal(doc)
+}
+
+func addNumbers(a, b any) any {
+	switch x := a.(type) {
+	case int32:
+		switch y := b.(type) {
+		case int32:
+			return x + y
+		case int64:
+			return int64(x) + y
+		}
+	case int64:
+		switch y := b.(type) {
+		case int32:
+			return x + int64(y)
+		case int64:
+			return x + y
+		}
+	}
+	fa, _ := toFloat(a)
+	fb, _ := toFloat(b)
+	return fa + fb
+}
+
+func setPath(doc bson.D, path string, value any) bson.D {
+	head, rest, nested := strings.Cut(path, ".")
+	for i, e := range doc {
+		if e.Key != head {
+			continue
+		}
+		if !nested {
+			doc[i].Value = value
+			return doc
+		}
+		sub, _ := e.Value.(bson.D)
+		doc[i].Value = setPath(sub, rest, value)
+		return doc
+	}
+	if nested {
+		return append(doc, bson.E{Key: head, Value: setPath(bson.D{}, rest, value)})
+	}
+	return append(doc, bson.E{Key: head, Value: value})
+}
+
+func unsetPath(doc bson.D, path string) bson.D {
+	head, rest, nested := strings.Cut(path, ".")
+	for i, e := range doc {
+		if e.Key != head {
+			continue
+		}
+		if !nested {
+			return append(doc[:i:i], doc[i+1:]...)
+		}
+		if sub, ok := e.Value.(bson.D); ok {
+			doc[i].Value = unsetPath(sub, rest)
+		}
+		return doc
+	}
+	return doc
+}
@@ -0,0 +1,828 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/search"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// DocumentTaskRepository implements TaskStore on top of an embedded
+// documentCollection instead of a MongoDB server.
+type DocumentTaskRepository struct {
+	Collection documentCollection
+}
+
+func (tr *DocumentTaskRepository) CreateTask(ctx context.Context, task *models.Task) error {
+	task.ID = primitive.NewObjectID()
+	task.Version = 1
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+
+	return tr.Collection.InsertOne(ctx, task)
+}
+
+func (tr *DocumentTaskRepository) GetTasks(ctx context.Context, filter bson.M, sort bson.D, limit, skip int) ([]models.Task, error) {
+	var raws []bson.Raw
+	var err error
+	if slices.ContainsFunc(sort, func(e bson.E) bool { return e.Key == NoDueDateKey }) && !sortsOn(tr.Collection, NoDueDateKey) {
+		raws, err = tr.Collection.Find(ctx, filter, nil, 0, 0)
+		if err == nil {
+			raws, err = sortByDueDate(raws, sort, limit, skip)
+		}
+	} else {
+		raws, err = tr.Collection.Find(ctx, filter, sort, limit, skip)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{}
+	for _, raw := range raws {
+		var task models.Task
+		if err := bson.Unmarshal(raw, &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// computedSorter is implemented by collections that keep computed sort keys,
+// such as NoDueDateKey, themselves.
+type computedSorter interface {
+	sortsOn(key string) bool
+}
+
+func sortsOn(coll documentCollection, key string) bool {
+	cs, ok := coll.(computedSorter)
+	return ok && cs.sortsOn(key)
+}
+
+// sortByDueDate sorts tasks with a sort that uses NoDueDateKey, by adding
+// the key to each document for the duration of the sort.
+func sortByDueDate(raws []bson.Raw, sort bson.D, limit, skip int) ([]bson.Raw, error) {
+	keyed := make([]bson.Raw, len(raws))
+	for i, raw := range raws {
+		doc, err := decodeDoc(raw)
+		if err != nil {
+			return nil, err
+		}
+		keyed[i], err = bson.Marshal(append(doc, bson.E{Key: NoDueDateKey, Value: noDueDate(doc)}))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filterDocuments(keyed, bson.M{}, sort, limit, skip)
+}
+
+// noDueDate is the value of NoDueDateKey for a task document.
+func noDueDate(doc bson.D) int32 {
+	if due, ok := lookup(doc, "due_date"); ok {
+		if dt, ok := due.(bson.DateTime); ok && dt > bson.NewDateTimeFromTime(time.Time{}) {
+			return 0
+		}
+	}
+	return 1
+}
+
+func (tr *DocumentTaskRepository) CountTasks(ctx context.Context, filter bson.M) (int64, error) {
+	raws, err := tr.Collection.Find(ctx, filter, nil, 0, 0)
+	if err != nil {
+		return 0, utils.Internal("Error counting tasks", nil)
+	}
+	return int64(len(raws)), nil
+}
+
+func (tr *DocumentTaskRepository) SearchTasks(ctx context.Context, filter bson.M, q *search.Query) ([]models.Task, error) {
+	candidates, err := tr.GetTasks(ctx, filter, nil, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error searching tasks", nil)
+	}
+
+	tasks := []models.Task{}
+	for i := range candidates {
+		if q.Match(search.TaskFields(&candidates[i])) {
+			tasks = append(tasks, candidates[i])
+		}
+	}
+	if len(tasks) > search.MaxMatches {
+		for i := range tasks {
+			tasks[i].Score = q.Score(search.TaskFields(&tasks[i]))
+		}
+		slices.SortStableFunc(tasks, func(a, b models.Task) int { return cmp(b.Score, a.Score) })
+		tasks = tasks[:search.MaxMatches]
+	}
+	return tasks, nil
+}
+
+func (tr *DocumentTaskRepository) GetTaskByID(ctx context.Context, id primitive.ObjectID) (*models.Task, error) {
+	raw, err := tr.Collection.FindOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("Task not found", nil)
+		}
+		return nil, utils.Internal("Error decoding task", nil)
+	}
+
+	var task models.Task
+	if err := bson.Unmarshal(raw, &task); err != nil {
+		return nil, utils.Internal("Error decoding task", nil)
+	}
+	return &task, nil
+}
+
+func (tr *DocumentTaskRepository) UpdateTask(ctx context.Context, id primitive.ObjectID, version int, set bson.M, unset []string) (*models.Task, error) {
+	matched, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), taskUpdate(set, unset))
+	if err != nil {
+		return nil, utils.Internal("Error updating task", nil)
+	}
+	if matched == 0 {
+		return nil, utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+
+	return tr.GetTaskByID(ctx, id)
+}
+
+// DeleteTask moves a task to the trash if it is still at version. It stays
+// in the collection until PurgeTasks removes it.
+func (tr *DocumentTaskRepository) DeleteTask(ctx context.Context, id primitive.ObjectID, version int, deletedAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"deleted_at": deletedAt, "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+
+	matched, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error deleting task", nil)
+	}
+	if matched == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+
+	return nil
+}
+
+func (tr *DocumentTaskRepository) RestoreTask(ctx context.Context, id primitive.ObjectID, version int) error {
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	matched, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error restoring task", nil)
+	}
+	if matched == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+// PurgeTasks permanently removes tasks trashed before deletedBefore and
+// returns their ids.
+func (tr *DocumentTaskRepository) PurgeTasks(ctx context.Context, deletedBefore time.Time) ([]primitive.ObjectID, error) {
+	purged, err := tr.GetTasks(ctx, bson.M{"deleted_at": bson.M{"$lt": deletedBefore}}, nil, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error purging tasks", nil)
+	}
+	if len(purged) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(purged))
+	for i, t := range purged {
+		ids[i] = t.ID
+	}
+	_, err = tr.Collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, utils.Internal("Error purging tasks", nil)
+	}
+	return ids, nil
+}
+
+func (tr *DocumentTaskRepository) AddBlocker(ctx context.Context, id primitive.ObjectID, version int, blockerID primitive.ObjectID) error {
+	update := bson.M{
+		"$addToSet": bson.M{"blocked_by": blockerID},
+		"$set":      bson.M{"updated_at": time.Now()},
+		"$inc":      bson.M{"version": 1},
+	}
+
+	matched, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error adding dependency", nil)
+	}
+	if matched == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+func (tr *DocumentTaskRepository) RemoveBlocker(ctx context.Context, id primitive.ObjectID, version int, blockerID primitive.ObjectID) error {
+	update := bson.M{
+		"$pull": bson.M{"blocked_by": blockerID},
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+
+	matched, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error removing dependency", nil)
+	}
+	if matched == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+func (tr *DocumentTaskRepository) AddTags(ctx context.Context, id primitive.ObjectID, version int, tags []string) error {
+	update := bson.M{
+		"$addToSet": bson.M{"tags": bson.M{"$each": tags}},
+		"$set":      bson.M{"updated_at": time.Now()},
+		"$inc":      bson.M{"version": 1},
+	}
+
+	matched, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error adding tags", nil)
+	}
+	if matched == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+func (tr *DocumentTaskRepository) RemoveTag(ctx context.Context, id primitive.ObjectID, version int, tag string) error {
+	update := bson.M{
+		"$pull": bson.M{"tags": tag},
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+
+	matched, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error removing tag", nil)
+	}
+	if matched == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+func (tr *DocumentTaskRepository) SetAssignees(ctx context.Context, id primitive.ObjectID, version int, assigneeIDs []primitive.ObjectID) (bool, error) {
+	update := bson.M{
+		"$set": bson.M{"assignee_ids": assigneeIDs, "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+
+	matched, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return false, utils.Internal("Error updating assignees", nil)
+	}
+	return matched == 1, nil
+}
+
+func (tr *DocumentTaskRepository) TagCounts(ctx context.Context, userID primitive.ObjectID) ([]models.TagCount, error) {
+	tasks, err := tr.GetTasks(ctx, bson.M{"user_id": userID, "deleted_at": nil}, nil, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error counting tags", nil)
+	}
+
+	byTag := map[string]int{}
+	for _, t := range tasks {
+		for _, tag := range t.Tags {
+			byTag[tag]++
+		}
+	}
+
+	counts := []models.TagCount{}
+	for tag, n := range byTag {
+		counts = append(counts, models.TagCount{Tag: tag, Count: n})
+	}
+	slices.SortFunc(counts, func(a, b models.TagCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return counts, nil
+}
+
+// DocumentUserRepository implements UserStore on top of an embedded
+// documentCollection instead of a MongoDB server.
+type DocumentUserRepository struct {
+	Collection documentCollection
+}
+
+func (ur *DocumentUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	raw, err := ur.Collection.FindOne(ctx, bson.M{"email": email})
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := bson.Unmarshal(raw, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (ur *DocumentUserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	user.ID = primitive.NewObjectID()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	err := ur.Collection.InsertOne(ctx, user)
+	if err != nil {
+		return utils.Internal("Error creating user", nil)
+	}
+	return nil
+}
+
+func (ur *DocumentUserRepository) VerifyEmail(ctx context.Context, token string) error {
+	filter := bson.M{
+		"verification_token":            token,
+		"verification_token_expires_at": bson.M{"$gt": time.Now()},
+	}
+	updates := bson.M{
+		"$set": bson.M{"verified": true},
+		"$unset": bson.M{
+			"verification_token":            "",
+			"verification_token_expires_at": "",
+		},
+	}
+
+	matched, err := ur.Collection.UpdateOne(ctx, filter, updates)
+	if err != nil {
+		return utils.Internal("Error updating user verification status", nil)
+	}
+
+	if matched == 0 {
+		return utils.BadRequest("Invalid or expired verification token", nil)
+	}
+
+	return nil
+}
+
+func (ur *DocumentUserRepository) UpdatePasswordToken(ctx context.Context, user *models.User) error {
+	filter := bson.M{"_id": user.ID, "email": user.Email}
+	updates := bson.M{
+		"$set": bson.M{
+			"password_reset_token":            user.PasswordResetToken,
+			"password_reset_token_expires_at": user.PasswordResetTokenExpiresAt,
+		},
+	}
+
+	_, err := ur.Collection.UpdateOne(ctx, filter, updates)
+	return err
+}
+
+func (ur *DocumentUserRepository) UpdatePassword(ctx context.Context, token string, req *models.UpdatePasswordRequest) error {
+	filter := bson.M{"password_reset_token": token, "password_reset_token_expires_at": bson.M{"$gt": time.Now()}}
+	updates := bson.M{
+		"$set": bson.M{"password": req.Password, "updated_at": time.Now()},
+		"$unset": bson.M{
+			"password_reset_token":            "",
+			"password_reset_token_expires_at": "",
+		},
+	}
+
+	matched, err := ur.Collection.UpdateOne(ctx, filter, updates)
+	if err != nil || matched == 0 {
+		return utils.BadRequest("Invalid or expired token", nil)
+	}
+	return nil
+}
+
+func (ur *DocumentUserRepository) GetUserByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	raw, err := ur.Collection.FindOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("User not found", nil)
+		}
+		return nil, utils.Internal("Error getting user", nil)
+	}
+
+	var user models.User
+	if err := bson.Unmarshal(raw, &user); err != nil {
+		return nil, utils.Internal("Error getting user", nil)
+	}
+	return &user, nil
+}
+
+func (ur *DocumentUserRepository) UpdateSettings(ctx context.Context, id primitive.ObjectID, settings models.UserSettings) error {
+	updates := bson.M{"$set": bson.M{"settings": settings, "updated_at": time.Now()}}
+
+	_, err := ur.Collection.UpdateOne(ctx, bson.M{"_id": id}, updates)
+	if err != nil {
+		return utils.Internal("Error updating settings", nil)
+	}
+	return nil
+}
+
+func (ur *DocumentUserRepository) GetDigestSubscribers(ctx context.Context) ([]models.User, error) {
+	raws, err := ur.Collection.Find(ctx, digestSubscribers, nil, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting users", nil)
+	}
+
+	users := []models.User{}
+	for _, raw := range raws {
+		var user models.User
+		if err := bson.Unmarshal(raw, &user); err != nil {
+			return nil, utils.Internal("Error getting users", nil)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (ur *DocumentUserRepository) SetCalendarToken(ctx context.Context, id primitive.ObjectID, token string) error {
+	updates := bson.M{"$set": bson.M{"calendar_token": token, "updated_at": time.Now()}}
+	if token == "" {
+		updates = bson.M{"$set": bson.M{"updated_at": time.Now()}, "$unset": bson.M{"calendar_token": ""}}
+	}
+
+	_, err := ur.Collection.UpdateOne(ctx, bson.M{"_id": id}, updates)
+	if err != nil {
+		return utils.Internal("Error updating calendar token", nil)
+	}
+	return nil
+}
+
+func (ur *DocumentUserRepository) GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	raw, err := ur.Collection.FindOne(ctx, bson.M{"calendar_token": token})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("Calendar not found", nil)
+		}
+		return nil, utils.Internal("Error getting calendar", nil)
+	}
+
+	var user models.User
+	if err := bson.Unmarshal(raw, &user); err != nil {
+		return nil, utils.Internal("Error getting calendar", nil)
+	}
+	return &user, nil
+}
+
+// DocumentProjectRepository implements ProjectStore on top of an embedded
+// documentCollection instead of a MongoDB server.
+type DocumentProjectRepository struct {
+	Collection documentCollection
+
+	// held while an inbox is looked up and created, so only one is
+	inboxMu sync.Mutex
+}
+
+func (pr *DocumentProjectRepository) CreateProject(ctx context.Context, project *models.Project) error {
+	project.ID = primitive.NewObjectID()
+	project.Version = 1
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = time.Now()
+
+	err := pr.Collection.InsertOne(ctx, project)
+	if err != nil {
+		return utils.Internal("Error creating project", nil)
+	}
+	return nil
+}
+
+func (pr *DocumentProjectRepository) GetProjects(ctx context.Context, filter bson.M) ([]models.Project, error) {
+	raws, err := pr.Collection.Find(ctx, filter, bson.D{{Key: "created_at", Value: 1}}, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting projects", nil)
+	}
+
+	projects := []models.Project{}
+	for _, raw := range raws {
+		var project models.Project
+		if err := bson.Unmarshal(raw, &project); err != nil {
+			return nil, utils.Internal("Error getting projects", nil)
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+func (pr *DocumentProjectRepository) GetProjectByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error) {
+	raw, err := pr.Collection.FindOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("Project not found", nil)
+		}
+		return nil, utils.Internal("Error decoding project", nil)
+	}
+
+	var project models.Project
+	if err := bson.Unmarshal(raw, &project); err != nil {
+		return nil, utils.Internal("Error decoding project", nil)
+	}
+	return &project, nil
+}
+
+func (pr *DocumentProjectRepository) EnsureInbox(ctx context.Context, ownerID primitive.ObjectID) (*models.Project, error) {
+	pr.inboxMu.Lock()
+	defer pr.inboxMu.Unlock()
+
+	inboxes, err := pr.GetProjects(ctx, bson.M{"owner_id": ownerID, "inbox": true})
+	if err != nil {
+		return nil, err
+	}
+	if len(inboxes) > 0 {
+		return &inboxes[0], nil
+	}
+
+	inbox := &models.Project{
+		OwnerID: ownerID,
+		Name:    "Inbox",
+		Inbox:   true,
+	}
+	if err := pr.CreateProject(ctx, inbox); err != nil {
+		return nil, err
+	}
+	return inbox, nil
+}
+
+func (pr *DocumentProjectRepository) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	project.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"name":       project.Name,
+			"color":      project.Color,
+			"archived":   project.Archived,
+			"updated_at": project.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	matched, err := pr.Collection.UpdateOne(ctx, versionFilter(project.ID, project.Version), update)
+	if err != nil {
+		return nil, utils.Internal("Error updating project", nil)
+	}
+	if matched == 0 {
+		return nil, utils.PreconditionFailed("Project was modified by someone else, reload it and try again", nil)
+	}
+	project.Version++
+	return project, nil
+}
+
+func (pr *DocumentProjectRepository) DeleteProject(ctx context.Context, id primitive.ObjectID) error {
+	_, err := pr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return utils.Internal("Error deleting project", nil)
+	}
+	return nil
+}
+
+func (pr *DocumentProjectRepository) UpdateMembers(ctx context.Context, id primitive.ObjectID, version int, members []models.ProjectMember) (bool, error) {
+	update := bson.M{"$set": bson.M{"members": members, "updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+
+	matched, err := pr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return false, utils.Internal("Error updating project members", nil)
+	}
+	return matched == 1, nil
+}
+
+// DocumentCommentRepository implements CommentStore on top of an embedded
+// documentCollection instead of a MongoDB server.
+type DocumentCommentRepository struct {
+	Collection documentCollection
+}
+
+func (cr *DocumentCommentRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
+	comment.ID = primitive.NewObjectID()
+	comment.CreatedAt = time.Now()
+
+	err := cr.Collection.InsertOne(ctx, comment)
+	if err != nil {
+		return utils.Internal("Error creating comment", nil)
+	}
+	return nil
+}
+
+func (cr *DocumentCommentRepository) GetComments(ctx context.Context, taskID primitive.ObjectID) ([]models.Comment, error) {
+	raws, err := cr.Collection.Find(ctx, bson.M{"task_id": taskID}, bson.D{{Key: "created_at", Value: 1}}, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting comments", nil)
+	}
+
+	comments := []models.Comment{}
+	for _, raw := range raws {
+		var comment models.Comment
+		if err := bson.Unmarshal(raw, &comment); err != nil {
+			return nil, utils.Internal("Error getting comments", nil)
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+func (cr *DocumentCommentRepository) GetCommentByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	raw, err := cr.Collection.FindOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("Comment not found", nil)
+		}
+		return nil, utils.Internal("Error decoding comment", nil)
+	}
+
+	var comment models.Comment
+	if err := bson.Unmarshal(raw, &comment); err != nil {
+		return nil, utils.Internal("Error decoding comment", nil)
+	}
+	return &comment, nil
+}
+
+func (cr *DocumentCommentRepository) UpdateComment(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	now := time.Now()
+	comment.EditedAt = &now
+	update := bson.M{"$set": bson.M{"body": comment.Body, "edited_at": comment.EditedAt}}
+
+	_, err := cr.Collection.UpdateOne(ctx, bson.M{"_id": comment.ID}, update)
+	if err != nil {
+		return nil, utils.Internal("Error updating comment", nil)
+	}
+	return comment, nil
+}
+
+func (cr *DocumentCommentRepository) DeleteComment(ctx context.Context, id primitive.ObjectID) error {
+	_, err := cr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return utils.Internal("Error deleting comment", nil)
+	}
+	return nil
+}
+
+func (cr *DocumentCommentRepository) DeleteTaskComments(ctx context.Context, taskIDs []primitive.ObjectID) error {
+	_, err := cr.Collection.DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+	if err != nil {
+		return utils.Internal("Error deleting comments", nil)
+	}
+	return nil
+}
+
+func (cr *DocumentCommentRepository) CommentCounts(ctx context.Context, taskIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	raws, err := cr.Collection.Find(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}}, nil, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error counting comments", nil)
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, raw := range raws {
+		var comment models.Comment
+		if err := bson.Unmarshal(raw, &comment); err != nil {
+			return nil, utils.Internal("Error counting comments", nil)
+		}
+		counts[comment.TaskID]++
+	}
+	return counts, nil
+}
+
+// DocumentHistoryRepository implements HistoryStore on top of an embedded
+// documentCollection instead of a MongoDB server.
+type DocumentHistoryRepository struct {
+	Collection documentCollection
+}
+
+func (hr *DocumentHistoryRepository) AppendHistory(ctx context.Context, entry *models.HistoryEntry) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	err := hr.Collection.InsertOne(ctx, entry)
+	if err != nil {
+		return utils.Internal("Error recording task history", nil)
+	}
+	return nil
+}
+
+func (hr *DocumentHistoryRepository) GetHistory(ctx context.Context, taskID primitive.ObjectID) ([]models.HistoryEntry, error) {
+	sort := bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+	raws, err := hr.Collection.Find(ctx, bson.M{"task_id": taskID}, sort, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting task history", nil)
+	}
+
+	entries := []models.HistoryEntry{}
+	for _, raw := range raws {
+		var entry models.HistoryEntry
+		if err := bson.Unmarshal(raw, &entry); err != nil {
+			return nil, utils.Internal("Error getting task history", nil)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// DocumentViewRepository implements ViewStore on top of an embedded
+// documentCollection instead of a MongoDB server.
+type DocumentViewRepository struct {
+	Collection documentCollection
+}
+
+func (vr *DocumentViewRepository) CreateView(ctx context.Context, view *models.SavedView) error {
+	view.ID = primitive.NewObjectID()
+	view.CreatedAt = time.Now()
+	view.UpdatedAt = time.Now()
+
+	err := vr.Collection.InsertOne(ctx, view)
+	if err != nil {
+		return utils.Internal("Error creating view", nil)
+	}
+	return nil
+}
+
+func (vr *DocumentViewRepository) GetViews(ctx context.Context, ownerID primitive.ObjectID) ([]models.SavedView, error) {
+	raws, err := vr.Collection.Find(ctx, bson.M{"owner_id": ownerID}, bson.D{{Key: "created_at", Value: 1}}, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting views", nil)
+	}
+
+	views := []models.SavedView{}
+	for _, raw := range raws {
+		var view models.SavedView
+		if err := bson.Unmarshal(raw, &view); err != nil {
+			return nil, utils.Internal("Error getting views", nil)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (vr *DocumentViewRepository) GetViewByID(ctx context.Context, id primitive.ObjectID) (*models.SavedView, error) {
+	raw, err := vr.Collection.FindOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("View not found", nil)
+		}
+		return nil, utils.Internal("Error decoding view", nil)
+	}
+
+	var view models.SavedView
+	if err := bson.Unmarshal(raw, &view); err != nil {
+		return nil, utils.Internal("Error decoding view", nil)
+	}
+	return &view, nil
+}
+
+func (vr *DocumentViewRepository) UpdateView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	view.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"name":       view.Name,
+		"filters":    view.Filters,
+		"sort":       view.Sort,
+		"order":      view.Order,
+		"updated_at": view.UpdatedAt,
+	}}
+
+	_, err := vr.Collection.UpdateOne(ctx, bson.M{"_id": view.ID}, update)
+	if err != nil {
+		return nil, utils.Internal("Error updating view", nil)
+	}
+	return view, nil
+}
+
+func (vr *DocumentViewRepository) DeleteView(ctx context.Context, id primitive.ObjectID) error {
+	_, err := vr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return utils.Internal("Error deleting view", nil)
+	}
+	return nil
+}
+
+// DocumentNotificationRepository implements NotificationStore on top of an
+// embedded documentCollection instead of a MongoDB server.
+type DocumentNotificationRepository struct {
+	Collection documentCollection
+}
+
+func (nr *DocumentNotificationRepository) ClaimNotification(ctx context.Context, n *models.Notification) (bool, error) {
+	n.SentAt = time.Now()
+
+	// both backends refuse a second document with the same _id
+	if err := nr.Collection.InsertOne(ctx, n); err != nil {
+		if _, findErr := nr.Collection.FindOne(ctx, bson.M{"_id": n.ID}); findErr == nil {
+			return false, nil
+		}
+		return false, utils.Internal("Error recording notification", nil)
+	}
+	return true, nil
+}
+
+func (nr *DocumentNotificationRepository) ReleaseNotification(ctx context.Context, id string) error {
+	_, err := nr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return utils.Internal("Error releasing notification", nil)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func mustMarshal(t *testing.T, doc any) bson.Raw {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// names returns the "name" field of each document, in order.
+func names(t *testing.T, raws []bson.Raw) []string {
+	t.Helper()
+	out := []string{}
+	for _, raw := range raws {
+		out = append(out, raw.Lookup("name").StringValue())
+	}
+	return out
+}
+
+func testDocuments(t *testing.T) []bson.Raw {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	docs := []bson.D{
+		{{Key: "name", Value: "a"}, {Key: "n", Value: 1}, {Key: "tags", Value: bson.A{"x", "y"}}, {Key: "due", Value: day},
+			{Key: "members", Value: bson.A{bson.D{{Key: "user", Value: "u1"}, {Key: "role", Value: "owner"}}}}},
+		{{Key: "name", Value: "b"}, {Key: "n", Value: 2}, {Key: "tags", Value: bson.A{"y"}}, {Key: "due", Value: day.AddDate(0, 0, 1)},
+			{Key: "members", Value: bson.A{bson.D{{Key: "user", Value: "u2"}, {Key: "role", Value: "viewer"}}}}},
+		{{Key: "name", Value: "c"}, {Key: "n", Value: 3.5}, {Key: "tags", Value: bson.A{}}, {Key: "gone", Value: nil}},
+		{{Key: "name", Value: "Delta"}, {Key: "n", Value: int64(4)}},
+	}
+	raws := make([]bson.Raw, len(docs))
+	for i, d := range docs {
+		raws[i] = mustMarshal(t, d)
+	}
+	return raws
+}
+
+func TestFilterDocuments(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter bson.M
+		want   []string
+	}{
+		{"empty", bson.M{}, []string{"a", "b", "c", "Delta"}},
+		{"equality", bson.M{"name": "b"}, []string{"b"}},
+		{"numbers of any width", bson.M{"n": int64(1)}, []string{"a"}},
+		{"array element", bson.M{"tags": "y"}, []string{"a", "b"}},
+		{"null matches missing", bson.M{"gone": nil}, []string{"a", "b", "c", "Delta"}},
+		{"$ne", bson.M{"name": bson.M{"$ne": "a"}}, []string{"b", "c", "Delta"}},
+		{"$ne null", bson.M{"due": bson.M{"$ne": nil}}, []string{"a", "b"}},
+		{"$gt", bson.M{"n": bson.M{"$gt": 2}}, []string{"c", "Delta"}},
+		{"$gte and $lt", bson.M{"n": bson.M{"$gte": 2, "$lt": 4}}, []string{"b", "c"}},
+		{"$lte date", bson.M{"due": bson.M{"$lte": day}}, []string{"a"}},
+		{"comparison skips other types", bson.M{"name": bson.M{"$gt": 0}}, []string{}},
+		{"$in", bson.M{"name": bson.M{"$in": bson.A{"a", "c"}}}, []string{"a", "c"}},
+		{"$in array field", bson.M{"tags": bson.M{"$in": bson.A{"x"}}}, []string{"a"}},
+		{"$in null", bson.M{"gone": bson.M{"$in": bson.A{nil, 5}}}, []string{"a", "b", "c", "Delta"}},
+		{"$nin", bson.M{"name": bson.M{"$nin": bson.A{"a", "c"}}}, []string{"b", "Delta"}},
+		{"$nin array field", bson.M{"tags": bson.M{"$nin": bson.A{"x"}}}, []string{"b", "c", "Delta"}},
+		{"$all", bson.M{"tags": bson.M{"$all": bson.A{"x", "y"}}}, []string{"a"}},
+		{"$all empty", bson.M{"tags": bson.M{"$all": bson.A{}}}, []string{}},
+		{"$size", bson.M{"tags": bson.M{"$size": 0}}, []string{"c"}},
+		{"$size skips non-arrays", bson.M{"name": bson.M{"$size": 1}}, []string{}},
+		{"dotted path through array", bson.M{"members.user": "u1"}, []string{"a"}},
+		{"dotted path $in", bson.M{"members.user": bson.M{"$in": bson.A{"u2", "u3"}}}, []string{"b"}},
+		{"$and", bson.M{"$and": []bson.M{{"tags": "y"}, {"n": bson.M{"$gt": 1}}}}, []string{"b"}},
+		{"$or", bson.M{"$or": []bson.M{{"name": "a"}, {"n": 4}}}, []string{"a", "Delta"}},
+		{"$nor", bson.M{"$nor": []bson.M{{"name": "a"}, {"n": 4}}}, []string{"b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterDocuments(testDocuments(t), tt.filter, nil, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g := names(t, got); !slices.Equal(g, tt.want) {
+				t.Errorf("got %v, want %v", g, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterDocumentsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter bson.M
+	}{
+		{"unknown operator", bson.M{"n": bson.M{"$near": 1}}},
+		{"unknown top level operator", bson.M{"$where": "true"}},
+		{"$eq", bson.M{"n": bson.M{"$eq": 2}}},
+		{"$exists", bson.M{"tags": bson.M{"$exists": false}}},
+		{"$regex", bson.M{"name": bson.M{"$regex": "^d"}}},
+		{"regex value", bson.M{"name": bson.Regex{Pattern: "^a"}}},
+		{"$not", bson.M{"n": bson.M{"$not": bson.M{"$gt": 1}}}},
+		{"$elemMatch", bson.M{"members": bson.M{"$elemMatch": bson.M{"user": "u2"}}}},
+		{"operator inside $in", bson.M{"n": bson.M{"$in": bson.A{bson.M{"$gt": 1}}}}},
+		{"$in without array", bson.M{"n": bson.M{"$in": 1}}},
+		{"$all without array", bson.M{"tags": bson.M{"$all": "x"}}},
+		{"$size without number", bson.M{"tags": bson.M{"$size": "0"}}},
+		{"$or without array", bson.M{"$or": bson.M{"n": 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := filterDocuments(testDocuments(t), tt.filter, nil, 0, 0); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFilterDocumentsSort(t *testing.T) {
+	tests := []struct {
+		name        string
+		sort        bson.D
+		limit, skip int
+		want        []string
+	}{
+		{"insertion order", nil, 0, 0, []string{"a", "b", "c", "Delta"}},
+		{"ascending", bson.D{{Key: "n", Value: 1}}, 0, 0, []string{"a", "b", "c", "Delta"}},
+		{"descending", bson.D{{Key: "n", Value: -1}}, 0, 0, []string{"Delta", "c", "b", "a"}},
+		{"missing sorts first", bson.D{{Key: "due", Value: 1}}, 0, 0, []string{"c", "Delta", "a", "b"}},
+		{"second key breaks ties", bson.D{{Key: "due", Value: -1}, {Key: "name", Value: -1}}, 0, 0, []string{"b", "a", "c", "Delta"}},
+		{"strings by byte", bson.D{{Key: "name", Value: 1}}, 0, 0, []string{"Delta", "a", "b", "c"}},
+		{"limit", bson.D{{Key: "n", Value: -1}}, 2, 0, []string{"Delta", "c"}},
+		{"skip", bson.D{{Key: "n", Value: -1}}, 0, 3, []string{"a"}},
+		{"skip and limit", bson.D{{Key: "n", Value: 1}}, 2, 1, []string{"b", "c"}},
+		{"skip past the end", nil, 0, 10, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterDocuments(testDocuments(t), bson.M{}, tt.sort, tt.limit, tt.skip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g := names(t, got); !slices.Equal(g, tt.want) {
+				t.Errorf("got %v, want %v", g, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	base := bson.D{
+		{Key: "name", Value: "a"},
+		{Key: "n", Value: int32(1)},
+		{Key: "tags", Value: bson.A{"x", "y"}},
+		{Key: "settings", Value: bson.D{{Key: "tz", Value: "UTC"}}},
+	}
+	tests := []struct {
+		name   string
+		update bson.M
+		field  string
+		want   any
+	}{
+		{"$set", bson.M{"$set": bson.M{"name": "b"}}, "name", "b"},
+		{"$set new field", bson.M{"$set": bson.M{"extra": true}}, "extra", true},
+		{"$set nested", bson.M{"$set": bson.M{"settings.tz": "Europe/Paris"}}, "settings.tz", "Europe/Paris"},
+		{"$set nested new", bson.M{"$set": bson.M{"other.deep": int32(1)}}, "other.deep", int32(1)},
+		{"$inc", bson.M{"$inc": bson.M{"n": 2}}, "n", int64(3)},
+		{"$inc missing", bson.M{"$inc": bson.M{"count": int32(1)}}, "count", int32(1)},
+		{"$addToSet", bson.M{"$addToSet": bson.M{"tags": "z"}}, "tags", bson.A{"x", "y", "z"}},
+		{"$addToSet present", bson.M{"$addToSet": bson.M{"tags": "x"}}, "tags", bson.A{"x", "y"}},
+		{"$addToSet $each", bson.M{"$addToSet": bson.M{"tags": bson.M{"$each": bson.A{"y", "z"}}}}, "tags", bson.A{"x", "y", "z"}},
+		{"$addToSet missing", bson.M{"$addToSet": bson.M{"ids": "a"}}, "ids", bson.A{"a"}},
+		{"$pull", bson.M{"$pull": bson.M{"tags": "x"}}, "tags", bson.A{"y"}},
+		{"$pull absent", bson.M{"$pull": bson.M{"tags": "z"}}, "tags", bson.A{"x", "y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := applyUpdate(mustMarshal(t, base), tt.update)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := decodeDoc(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := lookup(doc, tt.field)
+			if compareOrder(got, tt.want) != 0 || typeClass(got) != typeClass(tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyUpdateUnset(t *testing.T) {
+	base := bson.D{{Key: "a", Value: 1}, {Key: "b", Value: bson.D{{Key: "c", Value: 2}, {Key: "d", Value: 3}}}}
+	raw, err := applyUpdate(mustMarshal(t, base), bson.M{"$unset": bson.M{"a": "", "b.c": "", "missing": ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, _ := decodeDoc(raw)
+	if _, ok := lookup(doc, "a"); ok {
+		t.Error("a is still set")
+	}
+	if _, ok := lookup(doc, "b.c"); ok {
+		t.Error("b.c is still set")
+	}
+	if _, ok := lookup(doc, "b.d"); !ok {
+		t.Error("b.d was removed")
+	}
+}
+
+func TestApplyUpdateErrors(t *testing.T) {
+	raw := mustMarshal(t, bson.D{{Key: "a", Value: 1}})
+	for _, update := range []bson.M{
+		{"$rename": bson.M{"a": "b"}},
+		{"$push": bson.M{"tags": "x"}},
+		{"$setOnInsert": bson.M{"a": 2}},
+		{"$set": "a"},
+		{"$inc": bson.M{"a": "1"}},
+		{"$addToSet": bson.M{"tags": bson.M{"$slice": 1}}},
+		{"$pull": bson.M{"tags": bson.M{"$in": bson.A{"x"}}}},
+	} {
+		if _, err := applyUpdate(raw, update); err == nil {
+			t.Errorf("%v: expected an error", update)
+		}
+	}
+}
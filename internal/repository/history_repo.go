@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type HistoryRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewHistoryRepository(client *mongo.Client, dbName string) *HistoryRepository {
+	return &HistoryRepository{
+		Collection: client.Database(dbName).Collection("task_history"),
+	}
+}
+
+func (hr *HistoryRepository) AppendHistory(ctx context.Context, entry *models.HistoryEntry) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	_, err := hr.Collection.InsertOne(ctx, entry)
+	if err != nil {
+		return utils.Internal("Error recording task history", nil)
+	}
+	return nil
+}
+
+func (hr *HistoryRepository) GetHistory(ctx context.Context, taskID primitive.ObjectID) ([]models.HistoryEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := hr.Collection.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, utils.Internal("Error getting task history", nil)
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.HistoryEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, utils.Internal("Error getting task history", nil)
+	}
+	return entries, nil
+}
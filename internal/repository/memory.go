@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryCollection keeps documents in process memory. It is safe for
+// concurrent use and loses everything when the process exits.
+type memoryCollection struct {
+	mu   sync.RWMutex
+	docs []bson.Raw
+}
+
+func newMemoryCollection() *memoryCollection {
+	return &memoryCollection{docs: []bson.Raw{}}
+}
+
+func NewMemoryTaskRepository() *DocumentTaskRepository {
+	return &DocumentTaskRepository{Collection: newMemoryCollection()}
+}
+
+func NewMemoryUserRepository() *DocumentUserRepository {
+	return &DocumentUserRepository{Collection: newMemoryCollection()}
+}
+
+func NewMemoryProjectRepository() *DocumentProjectRepository {
+	return &DocumentProjectRepository{Collection: newMemoryCollection()}
+}
+
+func NewMemoryCommentRepository() *DocumentCommentRepository {
+	return &DocumentCommentRepository{Collection: newMemoryCollection()}
+}
+
+func NewMemoryHistoryRepository() *DocumentHistoryRepository {
+	return &DocumentHistoryRepository{Collection: newMemoryCollection()}
+}
+
+func NewMemoryViewRepository() *DocumentViewRepository {
+	return &DocumentViewRepository{Collection: newMemoryCollection()}
+}
+
+func NewMemoryNotificationRepository() *DocumentNotificationRepository {
+	return &DocumentNotificationRepository{Collection: newMemoryCollection()}
+}
+
+func (mc *memoryCollection) InsertOne(ctx context.Context, doc any) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	id, err := bson.Raw(raw).LookupErr("_id")
+	if err != nil {
+		return errors.New("document must have an _id")
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	for _, existing := range mc.docs {
+		if existing.Lookup("_id").Equal(id) {
+			return errors.New("duplicate key error: _id")
+		}
+	}
+	mc.docs = append(mc.docs, raw)
+	return nil
+}
+
+func (mc *memoryCollection) Find(ctx context.Context, filter bson.M, sort bson.D, limit, skip int) ([]bson.Raw, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	return filterDocuments(mc.docs, filter, sort, limit, skip)
+}
+
+func (mc *memoryCollection) FindOne(ctx context.Context, filter bson.M) (bson.Raw, error) {
+	docs, err := mc.Find(ctx, filter, nil, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return docs[0], nil
+}
+
+func (mc *memoryCollection) UpdateOne(ctx context.Context, filter bson.M, update bson.M) (int64, error) {
+	return mc.update(filter, update, 1)
+}
+
+func (mc *memoryCollection) UpdateMany(ctx context.Context, filter bson.M, update bson.M) (int64, error) {
+	return mc.update(filter, update, 0)
+}
+
+func (mc *memoryCollection) DeleteOne(ctx context.Context, filter bson.M) (int64, error) {
+	return mc.delete(filter, 1)
+}
+
+func (mc *memoryCollection) DeleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	return mc.delete(filter, 0)
+}
+
+func (mc *memoryCollection) update(filter bson.M, update bson.M, limit int) (int64, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	indexes, err := mc.indexesOf(filter, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := make([]bson.Raw, len(indexes))
+	for n, i := range indexes {
+		updated[n], err = applyUpdate(mc.docs[i], update)
+		if err != nil {
+			return 0, err
+		}
+	}
+	for n, i := range indexes {
+		mc.docs[i] = updated[n]
+	}
+	return int64(len(indexes)), nil
+}
+
+func (mc *memoryCollection) delete(filter bson.M, limit int) (int64, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	indexes, err := mc.indexesOf(filter, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for n := len(indexes) - 1; n >= 0; n-- {
+		i := indexes[n]
+		mc.docs = append(mc.docs[:i:i], mc.docs[i+1:]...)
+	}
+	return int64(len(indexes)), nil
+}
+
+// indexesOf returns the positions of documents matching filter, stopping
+// after limit matches when limit is positive. Callers must hold the lock.
+func (mc *memoryCollection) indexesOf(filter bson.M, limit int) ([]int, error) {
+	f, err := normalizeDoc(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []int{}
+	for i, raw := range mc.docs {
+		doc, err := decodeDoc(raw)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := matchDocument(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			indexes = append(indexes, i)
+			if limit > 0 && len(indexes) == limit {
+				break
+			}
+		}
+	}
+	return indexes, nil
+}
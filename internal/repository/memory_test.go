@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/search"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMemoryStores(t *testing.T) {
+	testStores(t, NewMemoryStores())
+}
+
+// testStores runs the same checks against any backend, so the embedded
+// backends are held to the behaviour of the MongoDB repositories.
+func testStores(t *testing.T, stores *Stores) {
+	t.Run("tasks", func(t *testing.T) { testTaskStore(t, stores.Tasks) })
+	t.Run("users", func(t *testing.T) { testUserStore(t, stores.Users) })
+}
+
+func testTaskStore(t *testing.T, tasks TaskStore) {
+	ctx := context.Background()
+	owner := primitive.NewObjectID()
+
+	task := &models.Task{UserID: owner, Title: "Write", Status: "pending", Priority: 2}
+	if err := tasks.CreateTask(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	if task.ID.IsZero() || task.Version != 1 {
+		t.Fatalf("created %+v", task)
+	}
+	other := &models.Task{UserID: primitive.NewObjectID(), Title: "Other", Status: "pending", Priority: 1}
+	if err := tasks.CreateTask(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tasks.GetTaskByID(ctx, task.ID)
+	if err != nil || got.Title != "Write" || got.UserID != owner {
+		t.Fatalf("get: %+v, %v", got, err)
+	}
+	if _, err := tasks.GetTaskByID(ctx, primitive.NewObjectID()); errorCode(err) != http.StatusNotFound {
+		t.Errorf("missing task: %v", err)
+	}
+
+	list, err := tasks.GetTasks(ctx, bson.M{"user_id": owner}, nil, 0, 0)
+	if err != nil || len(list) != 1 || list[0].ID != task.ID {
+		t.Fatalf("list: %+v, %v", list, err)
+	}
+
+	updated, err := tasks.UpdateTask(ctx, task.ID, 1, bson.M{"title": "Rewrite"}, []string{"description"})
+	if err != nil || updated.Title != "Rewrite" || updated.Version != 2 {
+		t.Fatalf("update: %+v, %v", updated, err)
+	}
+	if _, err := tasks.UpdateTask(ctx, task.ID, 1, bson.M{"title": "Stale"}, nil); errorCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("update at a stale version: %v", err)
+	}
+
+	// the other writes are versioned the same way
+	blocker := primitive.NewObjectID()
+	for _, write := range []struct {
+		name  string
+		apply func(version int) error
+	}{
+		{"add tags", func(v int) error { return tasks.AddTags(ctx, task.ID, v, []string{"a", "b"}) }},
+		{"remove tag", func(v int) error { return tasks.RemoveTag(ctx, task.ID, v, "a") }},
+		{"add blocker", func(v int) error { return tasks.AddBlocker(ctx, task.ID, v, blocker) }},
+		{"remove blocker", func(v int) error { return tasks.RemoveBlocker(ctx, task.ID, v, blocker) }},
+	} {
+		got, _ := tasks.GetTaskByID(ctx, task.ID)
+		if err := write.apply(got.Version - 1); errorCode(err) != http.StatusPreconditionFailed {
+			t.Errorf("%s at a stale version: %v", write.name, err)
+		}
+		if err := write.apply(got.Version); err != nil {
+			t.Errorf("%s: %v", write.name, err)
+		}
+	}
+	got, _ = tasks.GetTaskByID(ctx, task.ID)
+	if len(got.Tags) != 1 || got.Tags[0] != "b" || len(got.BlockedBy) != 0 || got.Version != 6 {
+		t.Errorf("after the writes %+v", got)
+	}
+
+	if err := tasks.DeleteTask(ctx, task.ID, 6, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.RestoreTask(ctx, task.ID, 6); errorCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("restore at a stale version: %v", err)
+	}
+	if err := tasks.RestoreTask(ctx, task.ID, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.DeleteTask(ctx, task.ID, 8, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	n, err := tasks.CountTasks(ctx, bson.M{"user_id": owner, "deleted_at": nil})
+	if err != nil || n != 0 {
+		t.Errorf("count after delete: %d, %v", n, err)
+	}
+}
+
+// TestMemorySearchCap checks that a search matching more than
+// search.MaxMatches tasks keeps the most relevant ones.
+func TestMemorySearchCap(t *testing.T) {
+	ctx := context.Background()
+	tasks := NewMemoryStores().Tasks
+	owner := primitive.NewObjectID()
+	create := func(title, description string) {
+		t.Helper()
+		if err := tasks.CreateTask(ctx, &models.Task{UserID: owner, Title: title, Description: description}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q, err := search.Parse("deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	create("Unrelated", "")
+	for range 3 {
+		create("Weak", "remember to deploy once everything else is done")
+	}
+	got, err := tasks.SearchTasks(ctx, bson.M{"user_id": owner}, q)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("under the cap: %d, %v", len(got), err)
+	}
+
+	for range search.MaxMatches {
+		create("Deploy", "")
+	}
+	got, err = tasks.SearchTasks(ctx, bson.M{"user_id": owner}, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != search.MaxMatches {
+		t.Fatalf("over the cap: %d", len(got))
+	}
+	for _, task := range got {
+		if task.Title != "Deploy" {
+			t.Fatalf("kept %q over a better match", task.Title)
+		}
+	}
+}
+
+func testUserStore(t *testing.T, users UserStore) {
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+
+	user := &models.User{Username: "alice", Email: "alice@example.com", VerificationToken: "verify", VerificationTokenExpiresAt: &expires}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := users.GetUserByEmail(ctx, "alice@example.com")
+	if err != nil || got.ID != user.ID {
+		t.Fatalf("by email: %+v, %v", got, err)
+	}
+	if _, err := users.GetUserByID(ctx, primitive.NewObjectID()); errorCode(err) != http.StatusNotFound {
+		t.Errorf("missing user: %v", err)
+	}
+
+	if err := users.VerifyEmail(ctx, "wrong"); errorCode(err) != http.StatusBadRequest {
+		t.Errorf("wrong token: %v", err)
+	}
+	if err := users.VerifyEmail(ctx, "verify"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = users.GetUserByID(ctx, user.ID)
+	if !got.Verified || got.VerificationToken != "" {
+		t.Errorf("verified %+v", got)
+	}
+	if err := users.VerifyEmail(ctx, "verify"); err == nil {
+		t.Error("token accepted twice")
+	}
+
+	past := time.Now().Add(-time.Minute)
+	user.PasswordResetToken, user.PasswordResetTokenExpiresAt = "reset", &past
+	if err := users.UpdatePasswordToken(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.UpdatePassword(ctx, "reset", &models.UpdatePasswordRequest{Password: "secret"}); err == nil {
+		t.Error("expired reset token accepted")
+	}
+}
+
+func errorCode(err error) int {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// notifications are only needed until what they were about is in the past
+const notificationRetention = 90 * 24 * time.Hour
+
+type NotificationRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewNotificationRepository(client *mongo.Client, dbName string) *NotificationRepository {
+	return &NotificationRepository{
+		Collection: client.Database(dbName).Collection("notifications"),
+	}
+}
+
+// EnsureIndexes expires notifications once they can no longer matter.
+func (nr *NotificationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := nr.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "sent_at", Value: 1}},
+		Options: options.Index().
+			SetName("notification_expiry").
+			SetExpireAfterSeconds(int32(notificationRetention.Seconds())),
+	})
+	return err
+}
+
+func (nr *NotificationRepository) ClaimNotification(ctx context.Context, n *models.Notification) (bool, error) {
+	n.SentAt = time.Now()
+
+	_, err := nr.Collection.InsertOne(ctx, n)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, utils.Internal("Error recording notification", nil)
+	}
+	return true, nil
+}
+
+func (nr *NotificationRepository) ReleaseNotification(ctx context.Context, id string) error {
+	_, err := nr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return utils.Internal("Error releasing notification", nil)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ProjectRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewProjectRepository(client *mongo.Client, dbName string) *ProjectRepository {
+	return &ProjectRepository{
+		Collection: client.Database(dbName).Collection("projects"),
+	}
+}
+
+// EnsureIndexes allows each user a single inbox.
+func (pr *ProjectRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := pr.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "owner_id", Value: 1}},
+		Options: options.Index().
+			SetName("project_inbox").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"inbox": true}),
+	})
+	return err
+}
+
+func (pr *ProjectRepository) CreateProject(ctx context.Context, project *models.Project) error {
+	project.ID = primitive.NewObjectID()
+	project.Version = 1
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = time.Now()
+
+	_, err := pr.Collection.InsertOne(ctx, project)
+	if err != nil {
+		return utils.Internal("Error creating project", nil)
+	}
+	return nil
+}
+
+func (pr *ProjectRepository) GetProjects(ctx context.Context, filter bson.M) ([]models.Project, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := pr.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, utils.Internal("Error getting projects", nil)
+	}
+	defer cursor.Close(ctx)
+
+	projects := []models.Project{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, utils.Internal("Error getting projects", nil)
+	}
+	return projects, nil
+}
+
+func (pr *ProjectRepository) GetProjectByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error) {
+	var project models.Project
+	err := pr.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&project)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("Project not found", nil)
+		}
+		return nil, utils.Internal("Error decoding project", nil)
+	}
+	return &project, nil
+}
+
+func (pr *ProjectRepository) EnsureInbox(ctx context.Context, ownerID primitive.ObjectID) (*models.Project, error) {
+	filter := bson.M{"owner_id": ownerID, "inbox": true}
+	now := time.Now()
+	update := bson.M{"$setOnInsert": &models.Project{
+		ID:        primitive.NewObjectID(),
+		OwnerID:   ownerID,
+		Name:      "Inbox",
+		Inbox:     true,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   1,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var inbox models.Project
+	err := pr.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&inbox)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent call inserted it first
+		err = pr.Collection.FindOne(ctx, filter).Decode(&inbox)
+	}
+	if err != nil {
+		return nil, utils.Internal("Error getting inbox", nil)
+	}
+	return &inbox, nil
+}
+
+func (pr *ProjectRepository) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	project.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"name":       project.Name,
+			"color":      project.Color,
+			"archived":   project.Archived,
+			"updated_at": project.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := pr.Collection.UpdateOne(ctx, versionFilter(project.ID, project.Version), update)
+	if err != nil {
+		return nil, utils.Internal("Error updating project", nil)
+	}
+	if result.MatchedCount == 0 {
+		return nil, utils.PreconditionFailed("Project was modified by someone else, reload it and try again", nil)
+	}
+	project.Version++
+	return project, nil
+}
+
+func (pr *ProjectRepository) DeleteProject(ctx context.Context, id primitive.ObjectID) error {
+	_, err := pr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return utils.Internal("Error deleting project", nil)
+	}
+	return nil
+}
+
+func (pr *ProjectRepository) UpdateMembers(ctx context.Context, id primitive.ObjectID, version int, members []models.ProjectMember) (bool, error) {
+	update := bson.M{"$set": bson.M{"members": members, "updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+
+	result, err := pr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return false, utils.Internal("Error updating project members", nil)
+	}
+	return result.MatchedCount == 1, nil
+}
@@ -0,0 +1,708 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// sqliteCollection stores bson documents in a SQLite table keyed by _id.
+// Every field the repositories filter or sort on is copied into a column of
+// its own, and filters, sorts, limits and skips on those columns run in
+// SQLite. A condition on any other field is still accepted: SQLite then only
+// narrows the rows down with the conditions it can check, and the rest of the
+// filter, the sort and the paging are applied in Go by the matcher the
+// in-memory backend uses, so both backends return the same documents.
+type sqliteCollection struct {
+	db      *sql.DB
+	table   string
+	columns []sqliteColumn
+}
+
+// sqliteColumn is a document field kept in a column. Scalar columns hold
+// ids, strings, numbers, booleans and dates. Array columns hold a JSON array
+// and are used for array fields and for paths through arrays of documents,
+// such as members.user_id. value, when set, computes the column from the
+// document instead of reading field.
+type sqliteColumn struct {
+	field string
+	array bool
+	value func(doc bson.D) any
+}
+
+func scalar(field string) sqliteColumn { return sqliteColumn{field: field} }
+func array(field string) sqliteColumn  { return sqliteColumn{field: field, array: true} }
+
+// newSQLiteCollection creates table with an indexed column for each of the
+// given fields.
+func newSQLiteCollection(db *sql.DB, table string, columns ...sqliteColumn) (*sqliteCollection, error) {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q (id BLOB PRIMARY KEY, doc BLOB NOT NULL)`, table)
+	if _, err := db.Exec(query); err != nil {
+		return nil, err
+	}
+	sc := &sqliteCollection{db: db, table: table, columns: columns}
+	if err := sc.addColumns(); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// addColumns adds the columns that tables created by an older version are
+// missing, fills them in from the stored documents and indexes them.
+func (sc *sqliteCollection) addColumns() error {
+	rows, err := sc.db.Query(fmt.Sprintf(`SELECT name FROM pragma_table_info(%s)`, sqliteString(sc.table)))
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	added := false
+	for _, column := range sc.columns {
+		if !existing[column.field] {
+			if _, err := sc.db.Exec(fmt.Sprintf(`ALTER TABLE %q ADD COLUMN %q`, sc.table, column.field)); err != nil {
+				return err
+			}
+			added = true
+		}
+		if column.array {
+			continue
+		}
+		index := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %q ON %q (%q)`, sc.table+"_"+column.field, sc.table, column.field)
+		if _, err := sc.db.Exec(index); err != nil {
+			return err
+		}
+	}
+	if !added {
+		return nil
+	}
+
+	tx, err := sc.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	docs, err := sc.query(context.Background(), tx, bson.M{}, nil, 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, raw := range docs {
+		if err := sc.write(context.Background(), tx, raw); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func NewSQLiteTaskRepository(db *sql.DB) (*DocumentTaskRepository, error) {
+	coll, err := newSQLiteCollection(db, "tasks",
+		scalar("user_id"), scalar("parent_id"), scalar("project_id"), scalar("next_occurrence_id"),
+		scalar("external_id"), scalar("title"), scalar("category"), scalar("status"), scalar("priority"), scalar("version"),
+		scalar("due_date"), scalar("created_at"), scalar("updated_at"), scalar("completed_at"), scalar("deleted_at"),
+		array("tags"), array("assignee_ids"), array("blocked_by"),
+		sqliteColumn{field: NoDueDateKey, value: func(doc bson.D) any { return noDueDate(doc) }},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentTaskRepository{Collection: coll}, nil
+}
+
+func NewSQLiteUserRepository(db *sql.DB) (*DocumentUserRepository, error) {
+	coll, err := newSQLiteCollection(db, "users",
+		scalar("email"), scalar("verified"), scalar("verification_token"), scalar("verification_token_expires_at"),
+		scalar("password_reset_token"), scalar("password_reset_token_expires_at"), scalar("calendar_token"),
+		scalar("settings.digest_frequency"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentUserRepository{Collection: coll}, nil
+}
+
+func NewSQLiteProjectRepository(db *sql.DB) (*DocumentProjectRepository, error) {
+	coll, err := newSQLiteCollection(db, "projects",
+		scalar("owner_id"), scalar("inbox"), scalar("archived"), scalar("version"), scalar("created_at"),
+		array("members.user_id"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentProjectRepository{Collection: coll}, nil
+}
+
+func NewSQLiteCommentRepository(db *sql.DB) (*DocumentCommentRepository, error) {
+	coll, err := newSQLiteCollection(db, "comments", scalar("task_id"), scalar("created_at"))
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentCommentRepository{Collection: coll}, nil
+}
+
+func NewSQLiteHistoryRepository(db *sql.DB) (*DocumentHistoryRepository, error) {
+	coll, err := newSQLiteCollection(db, "task_history", scalar("task_id"), scalar("created_at"))
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentHistoryRepository{Collection: coll}, nil
+}
+
+func NewSQLiteViewRepository(db *sql.DB) (*DocumentViewRepository, error) {
+	coll, err := newSQLiteCollection(db, "saved_views", scalar("owner_id"), scalar("created_at"))
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentViewRepository{Collection: coll}, nil
+}
+
+func NewSQLiteNotificationRepository(db *sql.DB) (*DocumentNotificationRepository, error) {
+	coll, err := newSQLiteCollection(db, "notifications")
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentNotificationRepository{Collection: coll}, nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// query returns the documents matching filter, sorted and paged. When the
+// filter and the sort only use columns, SQLite does all of it.
+func (sc *sqliteCollection) query(ctx context.Context, q queryer, filter bson.M, sort bson.D, limit, skip int) ([]bson.Raw, error) {
+	f, err := normalizeDoc(filter)
+	if err != nil {
+		return nil, err
+	}
+	clauses, args, exact := sc.where(f)
+	order, ordered := sc.orderBy(sort)
+	inSQL := exact && ordered
+
+	query := fmt.Sprintf(`SELECT doc FROM %q`, sc.table)
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	if inSQL {
+		query += " ORDER BY " + order
+		if limit != 0 || skip > 0 {
+			query += " LIMIT ? OFFSET ?"
+			args = append(args, sqlLimit(limit), max(skip, 0))
+		}
+	} else {
+		query += " ORDER BY rowid"
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []bson.Raw{}
+	for rows.Next() {
+		var doc []byte
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, bson.Raw(doc))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if inSQL {
+		return docs, nil
+	}
+	if exact {
+		filter = bson.M{}
+	}
+	return filterDocuments(docs, filter, sort, limit, skip)
+}
+
+// sqlLimit is limit as SQLite takes it, where -1 means no limit. Like
+// MongoDB, a negative limit is the same as a positive one.
+func sqlLimit(limit int) int {
+	switch {
+	case limit == 0:
+		return -1
+	case limit < 0:
+		return -limit
+	}
+	return limit
+}
+
+func (sc *sqliteCollection) InsertOne(ctx context.Context, doc any) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	id, err := bson.Raw(raw).LookupErr("_id")
+	if err != nil {
+		return errors.New("document must have an _id")
+	}
+
+	columns := "id, doc"
+	params := "?, ?"
+	args := []any{[]byte(id.Value), raw}
+	values, err := sc.columnValues(raw)
+	if err != nil {
+		return err
+	}
+	for i, column := range sc.columns {
+		columns += fmt.Sprintf(", %q", column.field)
+		params += ", ?"
+		args = append(args, values[i])
+	}
+	_, err = sc.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %q (%s) VALUES (%s)`, sc.table, columns, params), args...)
+	return err
+}
+
+// write stores raw over the document with the same _id.
+func (sc *sqliteCollection) write(ctx context.Context, q queryer, raw bson.Raw) error {
+	values, err := sc.columnValues(raw)
+	if err != nil {
+		return err
+	}
+	set := "doc = ?"
+	args := []any{[]byte(raw)}
+	for i, column := range sc.columns {
+		set += fmt.Sprintf(", %q = ?", column.field)
+		args = append(args, values[i])
+	}
+	args = append(args, []byte(raw.Lookup("_id").Value))
+	_, err = q.ExecContext(ctx, fmt.Sprintf(`UPDATE %q SET %s WHERE id = ?`, sc.table, set), args...)
+	return err
+}
+
+func (sc *sqliteCollection) Find(ctx context.Context, filter bson.M, sort bson.D, limit, skip int) ([]bson.Raw, error) {
+	return sc.query(ctx, sc.db, filter, sort, limit, skip)
+}
+
+func (sc *sqliteCollection) FindOne(ctx context.Context, filter bson.M) (bson.Raw, error) {
+	docs, err := sc.Find(ctx, filter, nil, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return docs[0], nil
+}
+
+func (sc *sqliteCollection) UpdateOne(ctx context.Context, filter bson.M, update bson.M) (int64, error) {
+	return sc.update(ctx, filter, update, 1)
+}
+
+func (sc *sqliteCollection) UpdateMany(ctx context.Context, filter bson.M, update bson.M) (int64, error) {
+	return sc.update(ctx, filter, update, 0)
+}
+
+func (sc *sqliteCollection) DeleteOne(ctx context.Context, filter bson.M) (int64, error) {
+	return sc.delete(ctx, filter, 1)
+}
+
+func (sc *sqliteCollection) DeleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	return sc.delete(ctx, filter, 0)
+}
+
+func (sc *sqliteCollection) update(ctx context.Context, filter bson.M, update bson.M, limit int) (int64, error) {
+	tx, err := sc.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	matched, err := sc.matching(ctx, tx, filter, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, raw := range matched {
+		updated, err := applyUpdate(raw, update)
+		if err != nil {
+			return 0, err
+		}
+		if err := sc.write(ctx, tx, updated); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(matched)), tx.Commit()
+}
+
+func (sc *sqliteCollection) delete(ctx context.Context, filter bson.M, limit int) (int64, error) {
+	tx, err := sc.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	matched, err := sc.matching(ctx, tx, filter, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %q WHERE id = ?`, sc.table)
+	for _, raw := range matched {
+		if _, err := tx.ExecContext(ctx, query, []byte(raw.Lookup("_id").Value)); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(matched)), tx.Commit()
+}
+
+// matching returns the documents matching filter inside tx, at most limit
+// of them when limit is positive.
+func (sc *sqliteCollection) matching(ctx context.Context, tx *sql.Tx, filter bson.M, limit int) ([]bson.Raw, error) {
+	return sc.query(ctx, tx, filter, nil, limit, 0)
+}
+
+// where translates the conditions of filter into SQL. exact reports whether
+// all of them were translated; otherwise the clauses select a superset of
+// the matching rows.
+func (sc *sqliteCollection) where(filter bson.D) (clauses []string, args []any, exact bool) {
+	exact = true
+	for _, e := range filter {
+		clause, a, ok := sc.condition(e)
+		if !ok {
+			exact = false
+			continue
+		}
+		clauses = append(clauses, clause)
+		args = append(args, a...)
+	}
+	return clauses, args, exact
+}
+
+// document translates every condition of filter, or reports false.
+func (sc *sqliteCollection) document(filter bson.D) (string, []any, bool) {
+	clauses, args, exact := sc.where(filter)
+	if !exact {
+		return "", nil, false
+	}
+	if len(clauses) == 0 {
+		return "1", nil, true
+	}
+	return "(" + strings.Join(clauses, " AND ") + ")", args, true
+}
+
+func (sc *sqliteCollection) condition(e bson.E) (string, []any, bool) {
+	switch e.Key {
+	case "$and", "$or", "$nor":
+		list, ok := e.Value.(bson.A)
+		if !ok {
+			return "", nil, false
+		}
+		clauses := []string{}
+		args := []any{}
+		for _, el := range list {
+			sub, ok := el.(bson.D)
+			if !ok {
+				return "", nil, false
+			}
+			clause, a, ok := sc.document(sub)
+			if !ok {
+				return "", nil, false
+			}
+			clauses = append(clauses, clause)
+			args = append(args, a...)
+		}
+		switch {
+		case e.Key == "$and" && len(clauses) == 0:
+			return "1", nil, true
+		case e.Key == "$and":
+			return "(" + strings.Join(clauses, " AND ") + ")", args, true
+		case len(clauses) == 0:
+			// no branch can match: $or selects nothing and $nor everything
+			return map[string]string{"$or": "0", "$nor": "1"}[e.Key], nil, true
+		case e.Key == "$or":
+			return "(" + strings.Join(clauses, " OR ") + ")", args, true
+		}
+		// a NULL from a comparison with a missing field must not turn
+		// NOT into NULL as well
+		return "NOT coalesce(" + strings.Join(clauses, " OR ") + ", 0)", args, true
+	case "_id":
+		return fieldCondition(`"id"`, false, idValue, e.Value)
+	}
+	for _, column := range sc.columns {
+		if column.field == e.Key {
+			encode := scalarValue
+			if column.array {
+				encode = elementValue
+			}
+			return fieldCondition(fmt.Sprintf("%q", column.field), column.array, encode, e.Value)
+		}
+	}
+	return "", nil, false
+}
+
+// fieldCondition translates the condition on a column: a plain value or
+// the $ne, $gt, $gte, $lt, $lte, $in, $nin, $all and $size operators. SQL
+// comparisons with NULL are never true, so null and missing values, which
+// are both NULL in a column, are matched explicitly where MongoDB matches
+// them. Array columns match when any element does, as array fields do in
+// MongoDB.
+func fieldCondition(column string, isArray bool, encode func(any) (any, bool), cond any) (string, []any, bool) {
+	// anyValue tests the value of a scalar column, or each element of an
+	// array column, with test, such as "= ?"
+	anyValue := func(test string) string {
+		if isArray {
+			return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE value %s)", column, test)
+		}
+		return column + " " + test
+	}
+	// in matches the values of list, null included
+	in := func(list bson.A) (string, []any, bool) {
+		values := []any{}
+		null := false
+		for _, el := range list {
+			if typeClass(el) == 1 {
+				null = true
+				continue
+			}
+			v, ok := encode(el)
+			if !ok {
+				return "", nil, false
+			}
+			values = append(values, v)
+		}
+		clause := anyValue("IN (" + placeholders(len(values)) + ")")
+		if null {
+			clause = fmt.Sprintf("(%s OR %s IS NULL)", clause, column)
+		}
+		return clause, values, true
+	}
+
+	ops, isOps := isOperatorDoc(cond)
+	if !isOps {
+		if typeClass(cond) == 1 {
+			return column + " IS NULL", nil, true
+		}
+		v, ok := encode(cond)
+		if !ok {
+			return "", nil, false
+		}
+		return anyValue("= ?"), []any{v}, true
+	}
+
+	compare := map[string]string{"$gt": ">", "$gte": ">=", "$lt": "<", "$lte": "<="}
+	clauses := []string{}
+	args := []any{}
+	for _, op := range ops {
+		switch op.Key {
+		case "$ne":
+			if typeClass(op.Value) == 1 {
+				clauses = append(clauses, column+" IS NOT NULL")
+				continue
+			}
+			v, ok := encode(op.Value)
+			if !ok {
+				return "", nil, false
+			}
+			clauses = append(clauses, fmt.Sprintf("NOT coalesce(%s, 0)", anyValue("= ?")))
+			args = append(args, v)
+		case "$gt", "$gte", "$lt", "$lte":
+			v, ok := encode(op.Value)
+			if !ok || typeClass(op.Value) == 1 {
+				return "", nil, false
+			}
+			clauses = append(clauses, anyValue(compare[op.Key]+" ?"))
+			args = append(args, v)
+		case "$in", "$nin":
+			list, ok := op.Value.(bson.A)
+			if !ok {
+				return "", nil, false
+			}
+			clause, values, ok := in(list)
+			if !ok {
+				return "", nil, false
+			}
+			if op.Key == "$nin" {
+				clause = fmt.Sprintf("NOT coalesce(%s, 0)", clause)
+			}
+			clauses = append(clauses, clause)
+			args = append(args, values...)
+		case "$all":
+			list, ok := op.Value.(bson.A)
+			if !ok {
+				return "", nil, false
+			}
+			if len(list) == 0 {
+				clauses = append(clauses, "0")
+			}
+			for _, el := range list {
+				v, ok := encode(el)
+				if !ok || typeClass(el) == 1 {
+					return "", nil, false
+				}
+				clauses = append(clauses, anyValue("= ?"))
+				args = append(args, v)
+			}
+		case "$size":
+			n, ok := toFloat(op.Value)
+			if !ok {
+				return "", nil, false
+			}
+			if !isArray {
+				clauses = append(clauses, "0")
+				continue
+			}
+			clauses = append(clauses, fmt.Sprintf("json_array_length(%s) = ?", column))
+			args = append(args, n)
+		default:
+			return "", nil, false
+		}
+	}
+	if len(clauses) == 0 {
+		return "", nil, false
+	}
+	return "(" + strings.Join(clauses, " AND ") + ")", args, true
+}
+
+// orderBy translates sort into an ORDER BY list, with the insertion order
+// breaking ties as it does in the matcher. SQLite puts NULL first in
+// ascending order and last in descending order, as MongoDB does.
+func (sc *sqliteCollection) orderBy(sort bson.D) (string, bool) {
+	terms := []string{}
+	for _, e := range sort {
+		column := ""
+		if e.Key == "_id" {
+			column = `"id"`
+		}
+		for _, c := range sc.columns {
+			if c.field == e.Key && !c.array {
+				column = fmt.Sprintf("%q", c.field)
+			}
+		}
+		if column == "" {
+			return "", false
+		}
+		if direction(e.Value) < 0 {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+	}
+	return strings.Join(append(terms, "rowid"), ", "), true
+}
+
+// idValue is _id as InsertOne stores it in the id column.
+func idValue(v any) (any, bool) {
+	if typeClass(v) == 0 || typeClass(v) == 1 || typeClass(v) == 4 || typeClass(v) == 5 {
+		return nil, false
+	}
+	_, data, err := bson.MarshalValue(v)
+	return data, err == nil
+}
+
+// columnValues returns the value of every column for raw.
+func (sc *sqliteCollection) columnValues(raw bson.Raw) ([]any, error) {
+	doc, err := decodeDoc(raw)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(sc.columns))
+	for i, column := range sc.columns {
+		if column.value != nil {
+			values[i] = column.value(doc)
+			continue
+		}
+		v, exists := lookup(doc, column.field)
+		if !exists || typeClass(v) == 1 {
+			continue
+		}
+		if !column.array {
+			values[i], _ = scalarValue(v)
+			continue
+		}
+		elements, ok := v.(bson.A)
+		if !ok {
+			elements = bson.A{v}
+		}
+		list := []any{}
+		for _, el := range elements {
+			if e, ok := elementValue(el); ok {
+				list = append(list, e)
+			}
+		}
+		data, err := json.Marshal(list)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = string(data)
+	}
+	return values, nil
+}
+
+// scalarValue converts a bson value to one SQLite orders the way the
+// matcher does within a type. The models' ObjectIDs are stored as binary.
+// Documents and arrays have no column value.
+func scalarValue(v any) (any, bool) {
+	switch x := v.(type) {
+	case nil, bson.Null, bson.Undefined:
+		return nil, true
+	case bson.ObjectID:
+		return x[:], true
+	case bson.Binary:
+		return x.Data, true
+	case bson.DateTime:
+		return int64(x), true
+	case string, int64, float64:
+		return x, true
+	case int32:
+		return int64(x), true
+	case int:
+		return int64(x), true
+	case bool:
+		if x {
+			return int64(1), true
+		}
+		return int64(0), true
+	}
+	return nil, false
+}
+
+// elementValue is scalarValue for an element of an array column, where
+// binary values are kept as hex strings since JSON has no bytes.
+func elementValue(v any) (any, bool) {
+	value, ok := scalarValue(v)
+	if b, isBytes := value.([]byte); isBytes {
+		return hex.EncodeToString(b), ok
+	}
+	return value, ok && value != nil
+}
+
+func placeholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// sqliteString quotes s as an SQL string literal.
+func sqliteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (sc *sqliteCollection) sortsOn(key string) bool {
+	_, ok := sc.orderBy(bson.D{{Key: key, Value: 1}})
+	return ok
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	_ "modernc.org/sqlite"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", t.TempDir()+"/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteStores(t *testing.T) {
+	stores, err := NewSQLiteStores(openSQLite(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStores(t, stores)
+}
+
+// TestSQLiteMatchesMemory runs the same queries on SQLite and in memory. The
+// table is created before its columns existed, so the columns are filled in
+// from the stored documents. exact says whether SQLite handles the whole
+// filter; the others fall back to the matcher and must still agree.
+func TestSQLiteMatchesMemory(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	if _, err := db.Exec(`CREATE TABLE "docs" (id BLOB PRIMARY KEY, doc BLOB NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	old := &sqliteCollection{db: db, table: "docs"}
+	mem := newMemoryCollection()
+
+	u1, u2, u3 := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	docs := []bson.M{
+		{"_id": primitive.NewObjectID(), "name": "a", "n": int32(1), "user_id": u1, "due": now, "done": true,
+			"tags": []string{"x", "y"}, "members": []bson.M{{"user": u2}}, "other": "o"},
+		{"_id": primitive.NewObjectID(), "name": "b", "n": int64(2), "user_id": u1, "due": time.Time{}, "done": false,
+			"tags": []string{"y"}, "members": []bson.M{{"user": u2}, {"user": u3}}},
+		{"_id": primitive.NewObjectID(), "name": "c", "n": 3.5, "user_id": u2, "due": now.Add(time.Hour),
+			"tags": []string{}},
+		{"_id": primitive.NewObjectID(), "name": "D", "user_id": u2, "due": nil},
+	}
+	for _, d := range docs {
+		if err := old.InsertOne(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+		if err := mem.InsertOne(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sc, err := newSQLiteCollection(db, "docs",
+		scalar("name"), scalar("n"), scalar("user_id"), scalar("due"), scalar("done"),
+		array("tags"), array("members.user"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := bson.D{{Key: "name", Value: 1}}
+	tests := []struct {
+		name        string
+		filter      bson.M
+		sort        bson.D
+		limit, skip int
+		exact       bool
+	}{
+		{"everything", bson.M{}, nil, 0, 0, true},
+		{"_id", bson.M{"_id": docs[2]["_id"]}, nil, 0, 0, true},
+		{"_id $in", bson.M{"_id": bson.M{"$in": []any{docs[0]["_id"], docs[3]["_id"]}}}, nil, 0, 0, true},
+		{"_id empty $in", bson.M{"_id": bson.M{"$in": []any{}}}, nil, 0, 0, true},
+		{"id", bson.M{"user_id": u1}, nil, 0, 0, true},
+		{"string", bson.M{"name": "c"}, nil, 0, 0, true},
+		{"number of another width", bson.M{"n": 2}, nil, 0, 0, true},
+		{"bool", bson.M{"done": false}, nil, 0, 0, true},
+		{"null matches missing", bson.M{"done": nil}, nil, 0, 0, true},
+		{"null matches null", bson.M{"due": nil}, nil, 0, 0, true},
+		{"$ne", bson.M{"name": bson.M{"$ne": "a"}}, nil, 0, 0, true},
+		{"$ne matches missing", bson.M{"n": bson.M{"$ne": 1}}, nil, 0, 0, true},
+		{"$ne null", bson.M{"due": bson.M{"$ne": nil}}, nil, 0, 0, true},
+		{"$gt", bson.M{"n": bson.M{"$gt": 1}}, nil, 0, 0, true},
+		{"date range", bson.M{"due": bson.M{"$gt": time.Time{}, "$lte": now}}, nil, 0, 0, true},
+		{"$in with null", bson.M{"n": bson.M{"$in": []any{1, nil}}}, nil, 0, 0, true},
+		{"$nin", bson.M{"n": bson.M{"$nin": []any{1, 2}}}, nil, 0, 0, true},
+		{"$nin with null", bson.M{"n": bson.M{"$nin": []any{1, nil}}}, nil, 0, 0, true},
+		{"array element", bson.M{"tags": "y"}, nil, 0, 0, true},
+		{"array $ne", bson.M{"tags": bson.M{"$ne": "x"}}, nil, 0, 0, true},
+		{"array $in", bson.M{"tags": bson.M{"$in": []string{"x", "z"}}}, nil, 0, 0, true},
+		{"array $nin", bson.M{"tags": bson.M{"$nin": []string{"x"}}}, nil, 0, 0, true},
+		{"array $all", bson.M{"tags": bson.M{"$all": []string{"x", "y"}}}, nil, 0, 0, true},
+		{"array $size", bson.M{"tags": bson.M{"$size": 0}}, nil, 0, 0, true},
+		{"array null", bson.M{"tags": nil}, nil, 0, 0, true},
+		{"path through array", bson.M{"members.user": u3}, nil, 0, 0, true},
+		{"path through array $in", bson.M{"members.user": bson.M{"$in": []any{u1, u2}}}, nil, 0, 0, true},
+		{"$and", bson.M{"$and": []bson.M{{"user_id": u2}, {"n": nil}}}, nil, 0, 0, true},
+		{"$or", bson.M{"$or": []bson.M{{"user_id": u2}, {"tags": "x"}}}, nil, 0, 0, true},
+		{"$nor", bson.M{"$nor": []bson.M{{"n": bson.M{"$gt": 1}}, {"name": "a"}}}, nil, 0, 0, true},
+		{"sort", bson.M{}, bson.D{{Key: "due", Value: -1}, {Key: "name", Value: 1}}, 0, 0, true},
+		{"sort nulls first", bson.M{}, bson.D{{Key: "n", Value: 1}}, 0, 0, true},
+		{"sort by _id", bson.M{}, bson.D{{Key: "_id", Value: -1}}, 0, 0, true},
+		{"limit and skip", bson.M{"user_id": bson.M{"$ne": nil}}, byName, 2, 1, true},
+		{"negative limit", bson.M{}, byName, -1, 0, true},
+		{"other field", bson.M{"other": "o"}, byName, 0, 0, false},
+		{"$or with other field", bson.M{"$or": []bson.M{{"user_id": u2}, {"other": "o"}}}, byName, 1, 0, false},
+		{"sort by other field", bson.M{"user_id": u1}, bson.D{{Key: "other", Value: -1}}, 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sc.Find(ctx, tt.filter, tt.sort, tt.limit, tt.skip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := mem.Find(ctx, tt.filter, tt.sort, tt.limit, tt.skip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g, w := names(t, got), names(t, want); !slices.Equal(g, w) {
+				t.Errorf("got %v, want %v", g, w)
+			}
+
+			f, err := normalizeDoc(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, exact := sc.where(f); exact != tt.exact {
+				t.Errorf("exact = %v", exact)
+			}
+		})
+	}
+
+	// updates keep the columns current
+	n, err := sc.UpdateMany(ctx, bson.M{"user_id": u1}, bson.M{"$set": bson.M{"user_id": u2}, "$addToSet": bson.M{"tags": "z"}})
+	if err != nil || n != 2 {
+		t.Fatalf("updated %d, %v", n, err)
+	}
+	got, err := sc.Find(ctx, bson.M{"user_id": u2, "tags": "z"}, nil, 0, 0)
+	if err != nil || len(got) != 2 {
+		t.Errorf("found %d, %v", len(got), err)
+	}
+	n, err = sc.UpdateOne(ctx, bson.M{"tags": "z"}, bson.M{"$pull": bson.M{"tags": "z"}})
+	if err != nil || n != 1 {
+		t.Fatalf("updated %d, %v", n, err)
+	}
+	n, err = sc.DeleteMany(ctx, bson.M{"tags": "z"})
+	if err != nil || n != 1 {
+		t.Errorf("deleted %d, %v", n, err)
+	}
+}
+
+// TestSQLiteTaskFilters checks that the filters the services build for task
+// listings run entirely in SQLite.
+func TestSQLiteTaskFilters(t *testing.T) {
+	tasks, err := NewSQLiteTaskRepository(openSQLite(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := tasks.Collection.(*sqliteCollection)
+	user, project := primitive.NewObjectID(), primitive.NewObjectID()
+
+	for _, filter := range []bson.M{
+		{"$or": []bson.M{{"user_id": user}, {"project_id": bson.M{"$in": []any{project}}}, {"assignee_ids": user}}, "deleted_at": nil},
+		{"user_id": user, "status": bson.M{"$ne": "completed"}, "due_date": bson.M{"$gt": time.Time{}, "$lt": time.Now()}},
+		{"tags": bson.M{"$all": []string{"a", "b"}}, "parent_id": nil, "category": "work", "priority": bson.M{"$gte": 3}},
+		{"blocked_by": bson.M{"$nin": []any{project}}, "external_id": bson.M{"$in": []string{"x"}}},
+		{"$nor": []bson.M{{"tags": "x"}}, "$and": []bson.M{{"$or": []bson.M{{"tags": nil}, {"tags": bson.M{"$size": 0}}}}}},
+		{"_id": user, "version": bson.M{"$in": bson.A{0, nil}}},
+	} {
+		f, err := normalizeDoc(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, exact := sc.where(f); !exact {
+			t.Errorf("%v is not handled by SQLite", filter)
+		}
+	}
+
+	for _, sort := range []string{"title", "priority", "status", "category", "due_date", "created_at", "updated_at", NoDueDateKey, "_id"} {
+		if !sc.sortsOn(sort) {
+			t.Errorf("cannot sort on %s in SQLite", sort)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/search"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// NoDueDateKey is a sort-only key that is 1 for tasks without a due date,
+// including a zero one, and 0 for the rest. Stores compute it when a sort
+// uses it; it is never stored and filters cannot refer to it.
+const NoDueDateKey = "no_due_date"
+
+// TaskStore is the persistence contract the task service depends on.
+// Filters and sorts use the MongoDB query shape regardless of backend.
+type TaskStore interface {
+	CreateTask(ctx context.Context, task *models.Task) error
+	GetTasks(ctx context.Context, filter bson.M, sort bson.D, limit, skip int) ([]models.Task, error)
+	CountTasks(ctx context.Context, filter bson.M) (int64, error)
+	// SearchTasks returns the tasks matching both filter and q, unordered,
+	// and no more than the search.MaxMatches most relevant of them.
+	SearchTasks(ctx context.Context, filter bson.M, q *search.Query) ([]models.Task, error)
+	GetTaskByID(ctx context.Context, id primitive.ObjectID) (*models.Task, error)
+	UpdateTask(ctx context.Context, id primitive.ObjectID, version int, set bson.M, unset []string) (*models.Task, error)
+	DeleteTask(ctx context.Context, id primitive.ObjectID, version int, deletedAt time.Time) error
+	// RestoreTask, AddBlocker, RemoveBlocker, AddTags and RemoveTag fail
+	// with 412 Precondition Failed unless the task is still at version.
+	RestoreTask(ctx context.Context, id primitive.ObjectID, version int) error
+	PurgeTasks(ctx context.Context, deletedBefore time.Time) ([]primitive.ObjectID, error)
+	AddBlocker(ctx context.Context, id primitive.ObjectID, version int, blockerID primitive.ObjectID) error
+	RemoveBlocker(ctx context.Context, id primitive.ObjectID, version int, blockerID primitive.ObjectID) error
+	AddTags(ctx context.Context, id primitive.ObjectID, version int, tags []string) error
+	RemoveTag(ctx context.Context, id primitive.ObjectID, version int, tag string) error
+	// SetAssignees replaces the assignees of the task if it is still at
+	// version, and reports whether it was.
+	SetAssignees(ctx context.Context, id primitive.ObjectID, version int, assigneeIDs []primitive.ObjectID) (bool, error)
+	TagCounts(ctx context.Context, userID primitive.ObjectID) ([]models.TagCount, error)
+}
+
+// ProjectStore is the persistence contract the project service depends on.
+type ProjectStore interface {
+	CreateProject(ctx context.Context, project *models.Project) error
+	GetProjects(ctx context.Context, filter bson.M) ([]models.Project, error)
+	GetProjectByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error)
+	// EnsureInbox returns the owner's inbox, creating it if there is none.
+	// Concurrent calls for one owner all get the same inbox.
+	EnsureInbox(ctx context.Context, ownerID primitive.ObjectID) (*models.Project, error)
+	// UpdateProject saves the name, color and archived flag, provided the
+	// project is still at project.Version.
+	UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	DeleteProject(ctx context.Context, id primitive.ObjectID) error
+	// UpdateMembers replaces the members of the project if it is still at
+	// version, and reports whether it was.
+	UpdateMembers(ctx context.Context, id primitive.ObjectID, version int, members []models.ProjectMember) (bool, error)
+}
+
+// CommentStore is the persistence contract the comment service depends on.
+type CommentStore interface {
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	GetComments(ctx context.Context, taskID primitive.ObjectID) ([]models.Comment, error)
+	GetCommentByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error)
+	UpdateComment(ctx context.Context, comment *models.Comment) (*models.Comment, error)
+	DeleteComment(ctx context.Context, id primitive.ObjectID) error
+	DeleteTaskComments(ctx context.Context, taskIDs []primitive.ObjectID) error
+	CommentCounts(ctx context.Context, taskIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error)
+}
+
+// HistoryStore is the append-only log of task changes.
+type HistoryStore interface {
+	AppendHistory(ctx context.Context, entry *models.HistoryEntry) error
+	GetHistory(ctx context.Context, taskID primitive.ObjectID) ([]models.HistoryEntry, error)
+}
+
+// ViewStore is the persistence contract the view service depends on.
+type ViewStore interface {
+	CreateView(ctx context.Context, view *models.SavedView) error
+	GetViews(ctx context.Context, ownerID primitive.ObjectID) ([]models.SavedView, error)
+	GetViewByID(ctx context.Context, id primitive.ObjectID) (*models.SavedView, error)
+	UpdateView(ctx context.Context, view *models.SavedView) (*models.SavedView, error)
+	DeleteView(ctx context.Context, id primitive.ObjectID) error
+}
+
+// UserStore is the persistence contract the user service depends on.
+type UserStore interface {
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	VerifyEmail(ctx context.Context, token string) error
+	UpdatePasswordToken(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, token string, req *models.UpdatePasswordRequest) error
+	// SetCalendarToken replaces the user's calendar feed token, or removes
+	// it when token is empty.
+	SetCalendarToken(ctx context.Context, id primitive.ObjectID, token string) error
+	GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error)
+	GetUserByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	UpdateSettings(ctx context.Context, id primitive.ObjectID, settings models.UserSettings) error
+	// GetDigestSubscribers returns the verified users who chose a daily or
+	// weekly digest.
+	GetDigestSubscribers(ctx context.Context) ([]models.User, error)
+}
+
+// NotificationStore records the emails that were sent.
+type NotificationStore interface {
+	// ClaimNotification stores n unless a notification with its ID exists,
+	// and reports whether it did. Only the caller that stored it may send it.
+	ClaimNotification(ctx context.Context, n *models.Notification) (bool, error)
+	// ReleaseNotification removes a claim whose email could not be sent, so
+	// that it is tried again.
+	ReleaseNotification(ctx context.Context, id string) error
+}
+
+// Indexer is implemented by stores that need indexes created before they
+// serve requests.
+type Indexer interface {
+	EnsureIndexes(ctx context.Context) error
+}
+
+var (
+	_ Indexer      = (*TaskRepository)(nil)
+	_ Indexer      = (*UserRepository)(nil)
+	_ Indexer      = (*NotificationRepository)(nil)
+	_ Indexer      = (*ProjectRepository)(nil)
+	_ TaskStore    = (*TaskRepository)(nil)
+	_ UserStore    = (*UserRepository)(nil)
+	_ TaskStore    = (*DocumentTaskRepository)(nil)
+	_ UserStore    = (*DocumentUserRepository)(nil)
+	_ ProjectStore = (*ProjectRepository)(nil)
+	_ ProjectStore = (*DocumentProjectRepository)(nil)
+	_ CommentStore = (*CommentRepository)(nil)
+	_ CommentStore = (*DocumentCommentRepository)(nil)
+	_ HistoryStore = (*HistoryRepository)(nil)
+	_ HistoryStore = (*DocumentHistoryRepository)(nil)
+	_ ViewStore    = (*ViewRepository)(nil)
+	_ ViewStore    = (*DocumentViewRepository)(nil)
+
+	_ NotificationStore = (*NotificationRepository)(nil)
+	_ NotificationStore = (*DocumentNotificationRepository)(nil)
+)
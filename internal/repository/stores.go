@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Stores bundles one implementation of every store for a storage backend.
+type Stores struct {
+	Tasks         TaskStore
+	Users         UserStore
+	Projects      ProjectStore
+	Comments      CommentStore
+	History       HistoryStore
+	Views         ViewStore
+	Notifications NotificationStore
+}
+
+// EnsureIndexes creates the indexes of every store that needs them.
+func (s *Stores) EnsureIndexes(ctx context.Context) error {
+	for _, store := range []any{s.Tasks, s.Users, s.Projects, s.Comments, s.History, s.Views, s.Notifications} {
+		if ix, ok := store.(Indexer); ok {
+			if err := ix.EnsureIndexes(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func NewMongoStores(client *mongo.Client, dbName string) *Stores {
+	return &Stores{
+		Tasks:         NewTaskRespository(client, dbName),
+		Users:         NewUserRespository(client, dbName),
+		Projects:      NewProjectRepository(client, dbName),
+		Comments:      NewCommentRepository(client, dbName),
+		History:       NewHistoryRepository(client, dbName),
+		Views:         NewViewRepository(client, dbName),
+		Notifications: NewNotificationRepository(client, dbName),
+	}
+}
+
+func NewMemoryStores() *Stores {
+	return &Stores{
+		Tasks:         NewMemoryTaskRepository(),
+		Users:         NewMemoryUserRepository(),
+		Projects:      NewMemoryProjectRepository(),
+		Comments:      NewMemoryCommentRepository(),
+		History:       NewMemoryHistoryRepository(),
+		Views:         NewMemoryViewRepository(),
+		Notifications: NewMemoryNotificationRepository(),
+	}
+}
+
+func NewSQLiteStores(db *sql.DB) (*Stores, error) {
+	tasks, err := NewSQLiteTaskRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	users, err := NewSQLiteUserRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := NewSQLiteProjectRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := NewSQLiteCommentRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	history, err := NewSQLiteHistoryRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	views, err := NewSQLiteViewRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := NewSQLiteNotificationRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stores{
+		Tasks:         tasks,
+		Users:         users,
+		Projects:      projects,
+		Comments:      comments,
+		History:       history,
+		Views:         views,
+		Notifications: notifications,
+	}, nil
+}
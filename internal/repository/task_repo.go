@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"task-manager/internal/models"
+	"task-manager/internal/search"
 	"task-manager/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -24,6 +26,7 @@ func NewTaskRespository(client *mongo.Client, dbName string) *TaskRepository {
 
 func (tr *TaskRepository) CreateTask(ctx context.Context, task *models.Task) error {
 	task.ID = primitive.NewObjectID()
+	task.Version = 1
 	task.CreatedAt = time.Now()
 	task.UpdatedAt = time.Now()
 
@@ -32,6 +35,10 @@ func (tr *TaskRepository) CreateTask(ctx context.Context, task *models.Task) err
 }
 
 func (tr *TaskRepository) GetTasks(ctx context.Context, filter bson.M, sort bson.D, limit, skip int) ([]models.Task, error) {
+	if slices.ContainsFunc(sort, func(e bson.E) bool { return e.Key == NoDueDateKey }) {
+		return tr.aggregateTasks(ctx, filter, sort, limit, skip)
+	}
+
 	opts := options.Find().SetSort(sort).SetLimit(int64(limit)).SetSkip(int64(skip))
 	cursor, err := tr.Collection.Find(ctx, filter, opts)
 	if err != nil {
@@ -46,6 +53,96 @@ func (tr *TaskRepository) GetTasks(ctx context.Context, filter bson.M, sort bson
 	return tasks, nil
 }
 
+// aggregateTasks is GetTasks for sorts that use NoDueDateKey, which only
+// exists inside an aggregation.
+func (tr *TaskRepository) aggregateTasks(ctx context.Context, filter bson.M, sort bson.D, limit, skip int) ([]models.Task, error) {
+	noDueDate := bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$due_date", time.Time{}}}, 0, 1}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{NoDueDateKey: noDueDate}}},
+		{{Key: "$sort", Value: sort}},
+	}
+	if skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: skip}})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$project", Value: bson.M{NoDueDateKey: 0}}})
+
+	cursor, err := tr.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (tr *TaskRepository) CountTasks(ctx context.Context, filter bson.M) (int64, error) {
+	n, err := tr.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, utils.Internal("Error counting tasks", nil)
+	}
+	return n, nil
+}
+
+// SearchTasks narrows the candidates with the text index, keeping the
+// search.MaxMatches that the index scores highest, then applies q itself so
+// phrases and exclusions behave exactly as on the other backends.
+func (tr *TaskRepository) SearchTasks(ctx context.Context, filter bson.M, q *search.Query) ([]models.Task, error) {
+	textFilter := bson.M{"$text": bson.M{"$search": q.String()}}
+	for k, v := range filter {
+		textFilter[k] = v
+	}
+	byScore := bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}
+
+	candidates, err := tr.GetTasks(ctx, textFilter, byScore, search.MaxMatches, 0)
+	if err != nil {
+		return nil, utils.Internal("Error searching tasks", nil)
+	}
+
+	tasks := []models.Task{}
+	for i := range candidates {
+		if q.Match(search.TaskFields(&candidates[i])) {
+			tasks = append(tasks, candidates[i])
+		}
+	}
+	return tasks, nil
+}
+
+// EnsureIndexes creates the text index used by SearchTasks and the index
+// imports use to look up external ids. The text index uses the "none"
+// language so words are matched whole, without stemming or stop words, the
+// same way search.Tokenize splits them.
+func (tr *TaskRepository) EnsureIndexes(ctx context.Context) error {
+	weights := bson.D{}
+	keys := bson.D{}
+	for _, field := range []string{"title", "tags", "description"} {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+		weights = append(weights, bson.E{Key: field, Value: search.Weights[field]})
+	}
+
+	_, err := tr.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: keys,
+			Options: options.Index().
+				SetName("task_text").
+				SetWeights(weights).
+				SetDefaultLanguage("none"),
+		},
+		{
+			Keys:    bson.D{{Key: "external_id", Value: 1}},
+			Options: options.Index().SetName("task_external_id").SetSparse(true),
+		},
+	})
+	return err
+}
+
 func (tr *TaskRepository) GetTaskByID(ctx context.Context, id primitive.ObjectID) (*models.Task, error) {
 	result := tr.Collection.FindOne(ctx, bson.M{"_id": id})
 
@@ -60,35 +157,218 @@ func (tr *TaskRepository) GetTaskByID(ctx context.Context, id primitive.ObjectID
 	return &task, nil
 }
 
-func (tr *TaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
-	filter := bson.M{"_id": task.ID}
+// UpdateTask sets and unsets only the given fields, provided the task is
+// still at version, and returns the task as stored afterwards.
+func (tr *TaskRepository) UpdateTask(ctx context.Context, id primitive.ObjectID, version int, set bson.M, unset []string) (*models.Task, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
+	var task models.Task
+	err := tr.Collection.FindOneAndUpdate(ctx, versionFilter(id, version), taskUpdate(set, unset), opts).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+		}
+		return nil, utils.Internal("Error updating task", nil)
+	}
+
+	return &task, nil
+}
+
+// versionFilter matches the task or project only while it is at version.
+// Documents written before versioning have no version field and count as
+// version 0.
+func versionFilter(id primitive.ObjectID, version int) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, "version": bson.M{"$in": bson.A{0, nil}}}
+	}
+	return bson.M{"_id": id, "version": version}
+}
+
+// taskUpdate builds a targeted update document. An empty $unset is left out
+// because MongoDB rejects it.
+func taskUpdate(set bson.M, unset []string) bson.M {
+	fields := bson.M{"updated_at": time.Now()}
+	for k, v := range set {
+		fields[k] = v
+	}
+
+	update := bson.M{"$set": fields, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		removed := bson.M{}
+		for _, k := range unset {
+			removed[k] = ""
+		}
+		update["$unset"] = removed
+	}
+	return update
+}
+
+// DeleteTask moves a task to the trash if it is still at version. It stays
+// in the collection until PurgeTasks removes it.
+func (tr *TaskRepository) DeleteTask(ctx context.Context, id primitive.ObjectID, version int, deletedAt time.Time) error {
 	update := bson.M{
-		"$set": bson.M{
-			"title":       task.Title,
-			"description": task.Description,
-			"status":      task.Status,
-			"priority":    task.Priority,
-			"due_date":    task.DueDate,
-			"updated_at":  time.Now(),
-		},
+		"$set": bson.M{"deleted_at": deletedAt, "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
 	}
 
-	_, err := tr.Collection.UpdateOne(ctx, filter, update)
+	res, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
 	if err != nil {
-		return nil, utils.Internal("Error updating task", nil)
+		return utils.Internal("Error deleting task", nil)
+	}
+	if res.MatchedCount == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
 	}
 
-	return task, nil
+	return nil
 }
 
-func (tr *TaskRepository) DeleteTask(ctx context.Context, id primitive.ObjectID) error {
-	_, err := tr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+func (tr *TaskRepository) RestoreTask(ctx context.Context, id primitive.ObjectID, version int) error {
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	res, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
 	if err != nil {
-		return utils.Internal("Error deleting task", nil)
+		return utils.Internal("Error restoring task", nil)
+	}
+	if res.MatchedCount == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+// PurgeTasks permanently removes tasks trashed before deletedBefore and
+// returns their ids.
+func (tr *TaskRepository) PurgeTasks(ctx context.Context, deletedBefore time.Time) ([]primitive.ObjectID, error) {
+	filter := bson.M{"deleted_at": bson.M{"$lt": deletedBefore}}
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := tr.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, utils.Internal("Error purging tasks", nil)
+	}
+	defer cursor.Close(ctx)
+
+	var purged []models.Task
+	if err := cursor.All(ctx, &purged); err != nil {
+		return nil, utils.Internal("Error purging tasks", nil)
+	}
+	if len(purged) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(purged))
+	for i, t := range purged {
+		ids[i] = t.ID
+	}
+	_, err = tr.Collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, utils.Internal("Error purging tasks", nil)
+	}
+	return ids, nil
+}
+
+func (tr *TaskRepository) AddBlocker(ctx context.Context, id primitive.ObjectID, version int, blockerID primitive.ObjectID) error {
+	update := bson.M{
+		"$addToSet": bson.M{"blocked_by": blockerID},
+		"$set":      bson.M{"updated_at": time.Now()},
+		"$inc":      bson.M{"version": 1},
+	}
+
+	res, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error adding dependency", nil)
+	}
+	if res.MatchedCount == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+func (tr *TaskRepository) RemoveBlocker(ctx context.Context, id primitive.ObjectID, version int, blockerID primitive.ObjectID) error {
+	update := bson.M{
+		"$pull": bson.M{"blocked_by": blockerID},
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+
+	res, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error removing dependency", nil)
+	}
+	if res.MatchedCount == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+func (tr *TaskRepository) AddTags(ctx context.Context, id primitive.ObjectID, version int, tags []string) error {
+	update := bson.M{
+		"$addToSet": bson.M{"tags": bson.M{"$each": tags}},
+		"$set":      bson.M{"updated_at": time.Now()},
+		"$inc":      bson.M{"version": 1},
 	}
 
+	res, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error adding tags", nil)
+	}
+	if res.MatchedCount == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
+	return nil
+}
+
+func (tr *TaskRepository) RemoveTag(ctx context.Context, id primitive.ObjectID, version int, tag string) error {
+	update := bson.M{
+		"$pull": bson.M{"tags": tag},
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+
+	res, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return utils.Internal("Error removing tag", nil)
+	}
+	if res.MatchedCount == 0 {
+		return utils.PreconditionFailed("Task was modified by someone else, reload it and try again", nil)
+	}
 	return nil
 }
 
+func (tr *TaskRepository) SetAssignees(ctx context.Context, id primitive.ObjectID, version int, assigneeIDs []primitive.ObjectID) (bool, error) {
+	update := bson.M{
+		"$set": bson.M{"assignee_ids": assigneeIDs, "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := tr.Collection.UpdateOne(ctx, versionFilter(id, version), update)
+	if err != nil {
+		return false, utils.Internal("Error updating assignees", nil)
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (tr *TaskRepository) TagCounts(ctx context.Context, userID primitive.ObjectID) ([]models.TagCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": nil}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := tr.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, utils.Internal("Error counting tags", nil)
+	}
+	defer cursor.Close(ctx)
+
+	counts := []models.TagCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, utils.Internal("Error counting tags", nil)
+	}
+	return counts, nil
+}
+
 // func (tr *TaskRepository) MarkCompleted(ctx context.Context, id primitive.ObjectID) error {}
@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type UserRepository struct {
@@ -22,6 +23,15 @@ func NewUserRespository(client *mongo.Client, dbName string) *UserRepository {
 	}
 }
 
+// EnsureIndexes creates the index calendar feeds are looked up by.
+func (ur *UserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := ur.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "calendar_token", Value: 1}},
+		Options: options.Index().SetName("user_calendar_token").SetUnique(true).SetSparse(true),
+	})
+	return err
+}
+
 func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	result := ur.Collection.FindOne(ctx, bson.M{"email": email})
@@ -109,3 +119,70 @@ func (ur *UserRepository) UpdatePassword(ctx context.Context, token string, req
 	return nil
 
 }
+
+func (ur *UserRepository) GetUserByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	var user models.User
+	err := ur.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("User not found", nil)
+		}
+		return nil, utils.Internal("Error getting user", nil)
+	}
+	return &user, nil
+}
+
+func (ur *UserRepository) UpdateSettings(ctx context.Context, id primitive.ObjectID, settings models.UserSettings) error {
+	updates := bson.M{"$set": bson.M{"settings": settings, "updated_at": time.Now()}}
+
+	_, err := ur.Collection.UpdateOne(ctx, bson.M{"_id": id}, updates)
+	if err != nil {
+		return utils.Internal("Error updating settings", nil)
+	}
+	return nil
+}
+
+func (ur *UserRepository) GetDigestSubscribers(ctx context.Context) ([]models.User, error) {
+	cursor, err := ur.Collection.Find(ctx, digestSubscribers)
+	if err != nil {
+		return nil, utils.Internal("Error getting users", nil)
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, utils.Internal("Error getting users", nil)
+	}
+	return users, nil
+}
+
+// digestSubscribers matches the users GetDigestSubscribers returns.
+var digestSubscribers = bson.M{
+	"verified":                  true,
+	"settings.digest_frequency": bson.M{"$in": []string{models.DigestDaily, models.DigestWeekly}},
+}
+
+func (ur *UserRepository) SetCalendarToken(ctx context.Context, id primitive.ObjectID, token string) error {
+	updates := bson.M{"$set": bson.M{"calendar_token": token, "updated_at": time.Now()}}
+	if token == "" {
+		updates = bson.M{"$set": bson.M{"updated_at": time.Now()}, "$unset": bson.M{"calendar_token": ""}}
+	}
+
+	_, err := ur.Collection.UpdateOne(ctx, bson.M{"_id": id}, updates)
+	if err != nil {
+		return utils.Internal("Error updating calendar token", nil)
+	}
+	return nil
+}
+
+func (ur *UserRepository) GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	var user models.User
+	err := ur.Collection.FindOne(ctx, bson.M{"calendar_token": token}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("Calendar not found", nil)
+		}
+		return nil, utils.Internal("Error getting calendar", nil)
+	}
+	return &user, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ViewRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewViewRepository(client *mongo.Client, dbName string) *ViewRepository {
+	return &ViewRepository{
+		Collection: client.Database(dbName).Collection("saved_views"),
+	}
+}
+
+func (vr *ViewRepository) CreateView(ctx context.Context, view *models.SavedView) error {
+	view.ID = primitive.NewObjectID()
+	view.CreatedAt = time.Now()
+	view.UpdatedAt = time.Now()
+
+	_, err := vr.Collection.InsertOne(ctx, view)
+	if err != nil {
+		return utils.Internal("Error creating view", nil)
+	}
+	return nil
+}
+
+func (vr *ViewRepository) GetViews(ctx context.Context, ownerID primitive.ObjectID) ([]models.SavedView, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := vr.Collection.Find(ctx, bson.M{"owner_id": ownerID}, opts)
+	if err != nil {
+		return nil, utils.Internal("Error getting views", nil)
+	}
+	defer cursor.Close(ctx)
+
+	views := []models.SavedView{}
+	if err := cursor.All(ctx, &views); err != nil {
+		return nil, utils.Internal("Error getting views", nil)
+	}
+	return views, nil
+}
+
+func (vr *ViewRepository) GetViewByID(ctx context.Context, id primitive.ObjectID) (*models.SavedView, error) {
+	var view models.SavedView
+	err := vr.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&view)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, utils.NotFound("View not found", nil)
+		}
+		return nil, utils.Internal("Error decoding view", nil)
+	}
+	return &view, nil
+}
+
+func (vr *ViewRepository) UpdateView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	view.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"name":       view.Name,
+		"filters":    view.Filters,
+		"sort":       view.Sort,
+		"order":      view.Order,
+		"updated_at": view.UpdatedAt,
+	}}
+
+	_, err := vr.Collection.UpdateOne(ctx, bson.M{"_id": view.ID}, update)
+	if err != nil {
+		return nil, utils.Internal("Error updating view", nil)
+	}
+	return view, nil
+}
+
+func (vr *ViewRepository) DeleteView(ctx context.Context, id primitive.ObjectID) error {
+	_, err := vr.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return utils.Internal("Error deleting view", nil)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"net/http"
+
+	"task-manager/internal/handlers"
+	"task-manager/internal/middleware"
+)
+
+func CalendarRouter(mux *http.ServeMux, h *handlers.CalendarHandler) {
+	mux.HandleFunc("POST /api/calendar/token", middleware.WithError(h.CreateFeedToken))
+	mux.HandleFunc("DELETE /api/calendar/token", middleware.WithError(h.RevokeFeedToken))
+	mux.HandleFunc("GET /api/calendar/feed.ics", middleware.WithError(h.GetFeed))
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"net/http"
+
+	"task-manager/internal/handlers"
+	"task-manager/internal/middleware"
+)
+
+func CommentRouter(mux *http.ServeMux, h *handlers.CommentHandler) {
+	mux.HandleFunc("GET /api/tasks/{id}/comments", middleware.WithError(h.GetComments))
+	mux.HandleFunc("POST /api/tasks/{id}/comments", middleware.WithError(h.CreateComment))
+	mux.HandleFunc("PUT /api/tasks/{id}/comments/{commentId}", middleware.WithError(h.UpdateComment))
+	mux.HandleFunc("DELETE /api/tasks/{id}/comments/{commentId}", middleware.WithError(h.DeleteComment))
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"net/http"
+
+	"task-manager/internal/handlers"
+	"task-manager/internal/middleware"
+)
+
+func ProjectRouter(mux *http.ServeMux, h *handlers.ProjectHandler) {
+	mux.HandleFunc("POST /api/projects", middleware.WithError(h.CreateProject))
+	mux.HandleFunc("GET /api/projects", middleware.WithError(h.GetProjects))
+	mux.HandleFunc("GET /api/projects/{id}", middleware.WithError(h.GetProject))
+	mux.HandleFunc("PUT /api/projects/{id}", middleware.WithError(h.UpdateProject))
+	mux.HandleFunc("DELETE /api/projects/{id}", middleware.WithError(h.DeleteProject))
+	mux.HandleFunc("POST /api/projects/{id}/members", middleware.WithError(h.InviteMember))
+	mux.HandleFunc("PUT /api/projects/{id}/members/{userId}", middleware.WithError(h.UpdateMember))
+	mux.HandleFunc("DELETE /api/projects/{id}/members/{userId}", middleware.WithError(h.RemoveMember))
+}
@@ -0,0 +1,331 @@
+package routes_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"task-manager/internal/handlers"
+	"task-manager/internal/middleware"
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/routes"
+	"task-manager/internal/services"
+	"task-manager/internal/utils"
+)
+
+// api is a server wired like cmd/task_manager, on the in-memory stores.
+type api struct {
+	t      *testing.T
+	srv    *httptest.Server
+	stores *repository.Stores
+}
+
+func newAPI(t *testing.T) *api {
+	t.Setenv("JWT_SECRET", "test-secret")
+	stores := repository.NewMemoryStores()
+
+	taskService := services.NewTaskService(stores.Tasks, stores.Projects, stores.Users, stores.Comments, stores.History)
+	projectService := services.NewProjectService(stores.Projects, taskService, stores.Users)
+
+	mux := http.NewServeMux()
+	routes.TaskRouter(mux, handlers.NewTaskHandler(taskService))
+	routes.ProjectRouter(mux, handlers.NewProjectHandler(projectService))
+	routes.CommentRouter(mux, handlers.NewCommentHandler(services.NewCommentService(stores.Comments, taskService)))
+
+	srv := httptest.NewServer(middleware.ApplyMiddleware(mux, middleware.JWTMiddleware))
+	t.Cleanup(srv.Close)
+	return &api{t: t, srv: srv, stores: stores}
+}
+
+// user creates a verified user and returns a token for them.
+func (a *api) user(name string) string {
+	a.t.Helper()
+	user := models.User{Username: name, Email: name + "@example.com", Verified: true}
+	if err := a.stores.Users.CreateUser(context.Background(), &user); err != nil {
+		a.t.Fatal(err)
+	}
+	token, err := utils.CreateTokenWithClaims(user)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return token
+}
+
+// do sends a request and decodes the data of the response into out, when
+// given.
+func (a *api) do(token, method, path string, body any, out any) int {
+	a.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			a.t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, a.srv.URL+path, &payload)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if out != nil {
+		envelope := struct {
+			Data any `json:"data"`
+		}{Data: out}
+		if err := json.NewDecoder(res.Body).Decode(&envelope); err != nil {
+			a.t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return res.StatusCode
+}
+
+// raw sends body as it is, with header, and returns the response for the
+// caller to close.
+func (a *api) raw(token, method, path string, header http.Header, body string) *http.Response {
+	a.t.Helper()
+	req, err := http.NewRequest(method, a.srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return res
+}
+
+func newTask(title string) map[string]any {
+	return map[string]any{"title": title, "description": "details", "category": "work", "priority": 2, "status": "pending"}
+}
+
+type taskList struct {
+	Count int           `json:"count"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+func TestTaskRoundTrip(t *testing.T) {
+	a := newAPI(t)
+	alice := a.user("alice")
+
+	if code := a.do("", "GET", "/api/tasks", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("without a token: %d", code)
+	}
+
+	var created models.Task
+	if code := a.do(alice, "POST", "/api/tasks", newTask("Write tests"), &created); code != http.StatusCreated {
+		t.Fatalf("create: %d", code)
+	}
+	if created.Title != "Write tests" || created.Version != 1 {
+		t.Fatalf("created %+v", created)
+	}
+
+	var got models.Task
+	if code := a.do(alice, "GET", "/api/tasks/"+created.ID.Hex(), nil, &got); code != http.StatusOK || got.ID != created.ID {
+		t.Fatalf("get: %d %+v", code, got)
+	}
+
+	var updated models.Task
+	update := map[string]any{"title": "Write more tests"}
+	if code := a.do(alice, "PUT", "/api/tasks/"+created.ID.Hex(), update, &updated); code != http.StatusOK {
+		t.Fatalf("update: %d", code)
+	}
+	if updated.Title != "Write more tests" || updated.Version != 2 {
+		t.Errorf("updated %+v", updated)
+	}
+
+	var list taskList
+	if code := a.do(alice, "GET", "/api/tasks", nil, &list); code != http.StatusOK || list.Count != 1 {
+		t.Fatalf("list: %d %+v", code, list)
+	}
+
+	bob := a.user("bob")
+	if code := a.do(bob, "GET", "/api/tasks/"+created.ID.Hex(), nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("another user's task: %d", code)
+	}
+
+	if code := a.do(alice, "DELETE", "/api/tasks/"+created.ID.Hex(), nil, nil); code != http.StatusOK {
+		t.Fatalf("delete: %d", code)
+	}
+	a.do(alice, "GET", "/api/tasks", nil, &list)
+	if list.Count != 0 {
+		t.Errorf("deleted task still listed")
+	}
+	a.do(alice, "GET", "/api/tasks/trash", nil, &list)
+	if list.Count != 1 {
+		t.Errorf("trash has %d tasks", list.Count)
+	}
+	if code := a.do(alice, "POST", "/api/tasks/"+created.ID.Hex()+"/restore", nil, nil); code != http.StatusOK {
+		t.Fatalf("restore: %d", code)
+	}
+	a.do(alice, "GET", "/api/tasks", nil, &list)
+	if list.Count != 1 {
+		t.Errorf("restored task not listed")
+	}
+}
+
+func TestDependencyCycle(t *testing.T) {
+	a := newAPI(t)
+	alice := a.user("alice")
+
+	tasks := make([]models.Task, 3)
+	for i := range tasks {
+		a.do(alice, "POST", "/api/tasks", newTask("Step "+string(rune('A'+i))), &tasks[i])
+	}
+	block := func(task, blocker models.Task) int {
+		return a.do(alice, "POST", "/api/tasks/"+task.ID.Hex()+"/dependencies", map[string]any{"blocker_id": blocker.ID.Hex()}, nil)
+	}
+
+	if code := block(tasks[1], tasks[0]); code != http.StatusOK {
+		t.Fatalf("B after A: %d", code)
+	}
+	if code := block(tasks[2], tasks[1]); code != http.StatusOK {
+		t.Fatalf("C after B: %d", code)
+	}
+	if code := block(tasks[0], tasks[2]); code != http.StatusConflict {
+		t.Errorf("A after C closes a cycle: %d", code)
+	}
+	if code := block(tasks[0], tasks[0]); code != http.StatusBadRequest {
+		t.Errorf("A after A: %d", code)
+	}
+}
+
+func TestDeleteProjectTrashesTasks(t *testing.T) {
+	a := newAPI(t)
+	alice := a.user("alice")
+
+	var project models.Project
+	if code := a.do(alice, "POST", "/api/projects", map[string]any{"name": "Launch"}, &project); code != http.StatusCreated {
+		t.Fatalf("create project: %d", code)
+	}
+	var parent, child models.Task
+	body := newTask("Announce")
+	body["project_id"] = project.ID.Hex()
+	a.do(alice, "POST", "/api/tasks", body, &parent)
+	if code := a.do(alice, "POST", "/api/tasks/"+parent.ID.Hex()+"/subtasks", newTask("Draft post"), &child); code != http.StatusCreated {
+		t.Fatalf("create subtask: %d", code)
+	}
+
+	if code := a.do(alice, "DELETE", "/api/projects/"+project.ID.Hex()+"?tasks=delete", nil, nil); code != http.StatusOK {
+		t.Fatalf("delete project: %d", code)
+	}
+	var list taskList
+	a.do(alice, "GET", "/api/tasks/trash", nil, &list)
+	if list.Count != 2 {
+		t.Fatalf("trash has %d tasks", list.Count)
+	}
+
+	// the project is gone, so the tasks come back in the inbox
+	if code := a.do(alice, "POST", "/api/tasks/"+parent.ID.Hex()+"/restore", nil, nil); code != http.StatusOK {
+		t.Fatalf("restore: %d", code)
+	}
+	var restored models.Task
+	a.do(alice, "GET", "/api/tasks/"+child.ID.Hex(), nil, &restored)
+	if restored.DeletedAt != nil || restored.ProjectID == nil || *restored.ProjectID == project.ID {
+		t.Errorf("restored subtask %+v", restored)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	a := newAPI(t)
+	alice := a.user("alice")
+
+	var task models.Task
+	if code := a.do(alice, "POST", "/api/tasks", newTask("Write"), &task); code != http.StatusCreated {
+		t.Fatalf("create: %d", code)
+	}
+	path := "/api/tasks/" + task.ID.Hex()
+	patch := http.Header{"Content-Type": {"application/merge-patch+json"}}
+	withTag := func(tag string) http.Header {
+		h := patch.Clone()
+		h.Set("If-Match", tag)
+		return h
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		code   int
+		etag   string
+	}{
+		{"plain JSON", http.Header{"Content-Type": {"application/json"}}, `{"priority": 4}`, http.StatusUnsupportedMediaType, ""},
+		{"not an object", patch, `[1]`, http.StatusBadRequest, ""},
+		{"unknown member", patch, `{"colour": "red"}`, http.StatusBadRequest, ""},
+		{"required member cleared", patch, `{"title": null}`, http.StatusBadRequest, ""},
+		{"weak tag", withTag(`W/"1"`), `{"priority": 4}`, http.StatusPreconditionFailed, ""},
+		{"set and clear", withTag(`"7", "1"`), `{"priority": 4, "description": null}`, http.StatusOK, `"2"`},
+		{"stale tag", withTag(`"1"`), `{"priority": 5}`, http.StatusPreconditionFailed, ""},
+		{"any tag", withTag("*"), `{"priority": 5}`, http.StatusOK, `"3"`},
+	}
+	for _, tt := range tests {
+		res := a.raw(alice, "PATCH", path, tt.header, tt.body)
+		res.Body.Close()
+		if res.StatusCode != tt.code || res.Header.Get("ETag") != tt.etag {
+			t.Errorf("%s: %d with ETag %s", tt.name, res.StatusCode, res.Header.Get("ETag"))
+		}
+	}
+
+	var got models.Task
+	a.do(alice, "GET", path, nil, &got)
+	if got.Title != "Write" || got.Description != "" || got.Priority != 5 || got.Version != 3 {
+		t.Errorf("patched %+v", got)
+	}
+}
+
+// TestIfMatchOnSubresources checks that the tag, dependency and assignee
+// endpoints honour If-Match and return the new ETag.
+func TestIfMatchOnSubresources(t *testing.T) {
+	a := newAPI(t)
+	alice := a.user("alice")
+
+	var task, blocker models.Task
+	a.do(alice, "POST", "/api/tasks", newTask("Write"), &task)
+	a.do(alice, "POST", "/api/tasks", newTask("Research"), &blocker)
+	path := "/api/tasks/" + task.ID.Hex()
+	plain := http.Header{"Content-Type": {"application/json"}}
+	withTag := func(tag string) http.Header {
+		h := plain.Clone()
+		h.Set("If-Match", tag)
+		return h
+	}
+
+	tests := []struct {
+		method, path string
+		body         string
+	}{
+		{"POST", path + "/tags", `{"tags": ["docs"]}`},
+		{"DELETE", path + "/tags/docs", ""},
+		{"POST", path + "/dependencies", `{"blocker_id": "` + blocker.ID.Hex() + `"}`},
+		{"DELETE", path + "/dependencies/" + blocker.ID.Hex(), ""},
+		{"PUT", path + "/assignees", `{"emails": []}`},
+	}
+	version := task.Version
+	for _, tt := range tests {
+		res := a.raw(alice, tt.method, tt.path, withTag(`"0"`), tt.body)
+		res.Body.Close()
+		if res.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("%s %s with a stale tag: %d", tt.method, tt.path, res.StatusCode)
+		}
+
+		res = a.raw(alice, tt.method, tt.path, withTag(strconv.Quote(strconv.Itoa(version))), tt.body)
+		res.Body.Close()
+		version++
+		if res.StatusCode != http.StatusOK || res.Header.Get("ETag") != strconv.Quote(strconv.Itoa(version)) {
+			t.Errorf("%s %s: %d with ETag %s", tt.method, tt.path, res.StatusCode, res.Header.Get("ETag"))
+		}
+	}
+}
@@ -10,6 +10,24 @@ import (
 func TaskRouter(mux *http.ServeMux, h *handlers.TaskHandler) {
 	mux.HandleFunc("POST /api/tasks", middleware.WithError(h.CreateTask))
 	mux.HandleFunc("GET /api/tasks", middleware.WithError(h.GetTasks))
+	mux.HandleFunc("POST /api/tasks/bulk", middleware.WithError(h.BulkTasks))
+	mux.HandleFunc("GET /api/tasks/export", middleware.WithError(h.ExportTasks))
+	mux.HandleFunc("POST /api/tasks/import", middleware.WithError(h.ImportTasks))
+	mux.HandleFunc("GET /api/tasks/{id}", middleware.WithError(h.GetTask))
 	mux.HandleFunc("PUT /api/tasks/{id}", middleware.WithError(h.UpdateTask))
+	mux.HandleFunc("PATCH /api/tasks/{id}", middleware.WithError(h.PatchTask))
 	mux.HandleFunc("DELETE /api/tasks/{id}", middleware.WithError(h.DeleteTask))
+	mux.HandleFunc("GET /api/tasks/trash", middleware.WithError(h.GetTrash))
+	mux.HandleFunc("POST /api/tasks/{id}/restore", middleware.WithError(h.RestoreTask))
+	mux.HandleFunc("POST /api/tasks/{id}/subtasks", middleware.WithError(h.CreateSubtask))
+	mux.HandleFunc("GET /api/tasks/{id}/subtasks", middleware.WithError(h.GetSubtasks))
+	mux.HandleFunc("POST /api/tasks/{id}/tags", middleware.WithError(h.AddTags))
+	mux.HandleFunc("DELETE /api/tasks/{id}/tags/{tag}", middleware.WithError(h.RemoveTag))
+	mux.HandleFunc("GET /api/tags", middleware.WithError(h.GetTags))
+	mux.HandleFunc("POST /api/tasks/{id}/dependencies", middleware.WithError(h.AddDependency))
+	mux.HandleFunc("DELETE /api/tasks/{id}/dependencies/{blockerId}", middleware.WithError(h.RemoveDependency))
+	mux.HandleFunc("POST /api/tasks/{id}/assignees", middleware.WithError(h.AssignTask))
+	mux.HandleFunc("PUT /api/tasks/{id}/assignees", middleware.WithError(h.ReassignTask))
+	mux.HandleFunc("DELETE /api/tasks/{id}/assignees/{userId}", middleware.WithError(h.UnassignTask))
+	mux.HandleFunc("GET /api/tasks/{id}/history", middleware.WithError(h.GetHistory))
 }
@@ -14,5 +14,7 @@ func UserRouter(mux *http.ServeMux, h *handlers.UserHandler) {
 	mux.HandleFunc("POST /api/auth/reset-password", middleware.WithError(h.ResetPassword))
 	mux.HandleFunc("GET /api/auth/verify-email", middleware.WithError(h.VerifyEmail))
 	mux.HandleFunc("POST /api/auth/resend-verification", middleware.WithError(h.ResendVerificationEmail))
+	mux.HandleFunc("GET /api/users/me", middleware.WithError(h.GetProfile))
+	mux.HandleFunc("PATCH /api/users/me/settings", middleware.WithError(h.UpdateSettings))
 
 }
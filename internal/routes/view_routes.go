@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"net/http"
+
+	"task-manager/internal/handlers"
+	"task-manager/internal/middleware"
+)
+
+func ViewRouter(mux *http.ServeMux, h *handlers.ViewHandler) {
+	mux.HandleFunc("GET /api/views", middleware.WithError(h.GetViews))
+	mux.HandleFunc("POST /api/views", middleware.WithError(h.CreateView))
+	mux.HandleFunc("GET /api/views/{id}", middleware.WithError(h.GetView))
+	mux.HandleFunc("PUT /api/views/{id}", middleware.WithError(h.UpdateView))
+	mux.HandleFunc("DELETE /api/views/{id}", middleware.WithError(h.DeleteView))
+	mux.HandleFunc("GET /api/views/{id}/tasks", middleware.WithError(h.GetViewTasks))
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxTerms caps how many words a query may contain, phrases included.
+const MaxTerms = 32
+
+// MaxMatches caps how many tasks a search ranks and counts. A search that
+// matches more tasks than this is only shown the most relevant of them and
+// should be narrowed down.
+const MaxMatches = 1000
+
+// Query is a parsed search such as `deploy "release notes" -staging`.
+// A task matches when it contains every phrase, none of the excluded
+// words or phrases and, if the query has no phrases, at least one term.
+type Query struct {
+	Terms    []string
+	Phrases  [][]string
+	Excluded [][]string
+}
+
+// Parse parses a search string. Words are split into lowercase tokens,
+// double quotes group words into a phrase and a leading "-" excludes the
+// word or phrase that follows.
+func Parse(s string) (*Query, error) {
+	q := &Query{}
+	runes := []rune(s)
+	words := 0
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		negate := false
+		if runes[i] == '-' {
+			negate = true
+			i++
+		}
+
+		var tokens []string
+		phrase := i < len(runes) && runes[i] == '"'
+		if phrase {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated phrase at position %d", i)
+			}
+			tokens = Tokenize(string(runes[i+1 : end]))
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			tokens = Tokenize(string(runes[i:end]))
+			i = end
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		words += len(tokens)
+		if words > MaxTerms {
+			return nil, fmt.Errorf("search has more than %d words", MaxTerms)
+		}
+
+		switch {
+		case negate:
+			q.Excluded = append(q.Excluded, tokens)
+		case phrase:
+			q.Phrases = append(q.Phrases, tokens)
+		default:
+			// "follow-up" searches for both words, like the text index does
+			q.Terms = append(q.Terms, tokens...)
+		}
+	}
+
+	if len(q.Terms) == 0 && len(q.Phrases) == 0 {
+		return nil, fmt.Errorf("search needs at least one word that is not excluded")
+	}
+	return q, nil
+}
+
+// String renders q in MongoDB $text syntax.
+func (q *Query) String() string {
+	parts := append([]string{}, q.Terms...)
+	for _, p := range q.Phrases {
+		parts = append(parts, `"`+strings.Join(p, " ")+`"`)
+	}
+	for _, p := range q.Excluded {
+		if len(p) == 1 {
+			parts = append(parts, "-"+p[0])
+		} else {
+			parts = append(parts, `-"`+strings.Join(p, " ")+`"`)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Tokenize splits text into lowercase words. Anything that is not a letter
+// or digit separates words.
+func Tokenize(text string) []string {
+	tokens := []string{}
+	for _, t := range scan(text) {
+		tokens = append(tokens, t.word)
+	}
+	return tokens
+}
+
+type token struct {
+	word       string
+	start, end int
+}
+
+// scan is Tokenize keeping each word's byte offsets in text.
+func scan(text string) []token {
+	tokens := []token{}
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		}
+		if !word && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"  ", []string{}},
+		{"Deploy", []string{"deploy"}},
+		{"follow-up e-mail", []string{"follow", "up", "e", "mail"}},
+		{"v2.1 release!", []string{"v2", "1", "release"}},
+		{"Ünïcode Straße", []string{"ünïcode", "straße"}},
+		{"tabs\tand\nnewlines", []string{"tabs", "and", "newlines"}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		errors bool
+	}{
+		{in: "deploy", want: "deploy"},
+		{in: "  Deploy   Staging ", want: "deploy staging"},
+		{in: "follow-up", want: "follow up"},
+		{in: `"Release Notes" deploy`, want: `deploy "release notes"`},
+		{in: `deploy -staging -"old notes"`, want: `deploy -staging -"old notes"`},
+		{in: `"notes" -`, want: `"notes"`},
+		{in: `""  deploy`, want: "deploy"},
+		{in: "", errors: true},
+		{in: "-staging", errors: true},
+		{in: `-"old notes"`, errors: true},
+		{in: `"unterminated`, errors: true},
+		{in: strings.Repeat("word ", MaxTerms), want: strings.TrimSpace(strings.Repeat("word ", MaxTerms))},
+		{in: strings.Repeat("word ", MaxTerms+1), errors: true},
+		{in: strings.Repeat("word ", MaxTerms-1) + `"two words"`, errors: true},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.in)
+		if tt.errors {
+			if err == nil {
+				t.Errorf("Parse(%q) = %q, want an error", tt.in, q)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got := q.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
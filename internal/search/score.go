@@ -0,0 +1,179 @@
+package search
+
+import (
+	"html"
+	"math"
+	"slices"
+	"strings"
+
+	"task-manager/internal/models"
+)
+
+// Weights ranks a match in the title above one in the tags, and both above
+// the description. The MongoDB text index uses the same weights.
+var Weights = map[string]int{
+	"title":       3,
+	"tags":        2,
+	"description": 1,
+}
+
+// snippet sizing for Highlight
+const (
+	snippetLead  = 6
+	snippetBytes = 160
+)
+
+// Field is one searchable piece of a task.
+type Field struct {
+	Name string
+	Text string
+}
+
+// TaskFields returns the searchable fields of a task.
+func TaskFields(task *models.Task) []Field {
+	return []Field{
+		{Name: "title", Text: task.Title},
+		{Name: "tags", Text: strings.Join(task.Tags, " ")},
+		{Name: "description", Text: task.Description},
+	}
+}
+
+// Match reports whether fields satisfy q.
+func (q *Query) Match(fields []Field) bool {
+	tokens := make([][]string, len(fields))
+	for i, f := range fields {
+		tokens[i] = Tokenize(f.Text)
+	}
+	anywhere := func(p []string) bool {
+		return slices.ContainsFunc(tokens, func(t []string) bool { return count(t, p) > 0 })
+	}
+
+	for _, p := range q.Excluded {
+		if anywhere(p) {
+			return false
+		}
+	}
+	for _, p := range q.Phrases {
+		if !anywhere(p) {
+			return false
+		}
+	}
+	if len(q.Phrases) > 0 {
+		return true
+	}
+	return slices.ContainsFunc(q.Terms, func(t string) bool { return anywhere([]string{t}) })
+}
+
+// Score ranks fields against q. Every occurrence of a term or phrase counts
+// its field's weight, phrases once per word, and long fields are damped so
+// a short title that matches beats a long description that happens to.
+func (q *Query) Score(fields []Field) float64 {
+	score := 0.0
+	for _, f := range fields {
+		tokens := Tokenize(f.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+		hits := 0
+		for _, t := range q.Terms {
+			hits += count(tokens, []string{t})
+		}
+		for _, p := range q.Phrases {
+			hits += count(tokens, p) * len(p)
+		}
+		score += float64(Weights[f.Name]*hits) / math.Sqrt(float64(len(tokens)))
+	}
+	return math.Round(score*1000) / 1000
+}
+
+// Highlight returns an HTML-escaped excerpt of text around the first match
+// of q with every match wrapped in <mark>, or "" when text does not match.
+func (q *Query) Highlight(text string) string {
+	tokens := scan(text)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.word
+	}
+
+	// marked[i] is set for every token that belongs to a match
+	marked := make([]bool, len(tokens))
+	first := -1
+	mark := func(i, n int) {
+		for j := i; j < i+n; j++ {
+			marked[j] = true
+		}
+		if first < 0 || i < first {
+			first = i
+		}
+	}
+	for i := range words {
+		if slices.Contains(q.Terms, words[i]) {
+			mark(i, 1)
+		}
+		for _, p := range q.Phrases {
+			if hasPrefix(words[i:], p) {
+				mark(i, len(p))
+			}
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	from := max(first-snippetLead, 0)
+	to := first
+	for to+1 < len(tokens) && tokens[to+1].end-tokens[from].start <= snippetBytes {
+		to++
+	}
+
+	var b strings.Builder
+	start := tokens[from].start
+	if from > 0 {
+		b.WriteString("…")
+	}
+	for i := from; i <= to; i++ {
+		t := tokens[i]
+		b.WriteString(html.EscapeString(text[start:t.start]))
+		if marked[i] && (i == from || !marked[i-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteString(html.EscapeString(text[t.start:t.end]))
+		start = t.end
+		// a run of marked words shares one <mark>, separators included
+		if marked[i] && (i == to || !marked[i+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+	if to == len(tokens)-1 {
+		b.WriteString(html.EscapeString(text[start:]))
+	} else {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// Highlights returns the excerpt of every field in fields that matches q.
+func (q *Query) Highlights(fields []Field) map[string]string {
+	out := map[string]string{}
+	for _, f := range fields {
+		if h := q.Highlight(f.Text); h != "" {
+			out[f.Name] = h
+		}
+	}
+	return out
+}
+
+// count returns how often phrase occurs in tokens.
+func count(tokens, phrase []string) int {
+	n := 0
+	for i := range tokens {
+		if hasPrefix(tokens[i:], phrase) {
+			n++
+		}
+	}
+	return n
+}
+
+func hasPrefix(tokens, phrase []string) bool {
+	return len(tokens) >= len(phrase) && slices.Equal(tokens[:len(phrase)], phrase)
+}
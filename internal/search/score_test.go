@@ -0,0 +1,91 @@
+package search
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+
+	"task-manager/internal/models"
+)
+
+func mustParse(t *testing.T, s string) *Query {
+	t.Helper()
+	q, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestMatch(t *testing.T) {
+	task := &models.Task{
+		Title:       "Deploy the API",
+		Description: "Write release notes for the staging rollout",
+		Tags:        []string{"backend", "follow-up"},
+	}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"deploy", true},
+		{"DEPLOY", true},
+		{"depl", false},
+		{"frontend deploy", true},
+		{"frontend mobile", false},
+		{"backend", true},
+		{"follow-up", true},
+		{`"release notes"`, true},
+		{`"notes release"`, false},
+		{`"release notes" "production"`, false},
+		{`"api" frontend`, true},
+		{"deploy -staging", false},
+		{`deploy -"staging rollout"`, false},
+		{`deploy -"rollout staging"`, true},
+		{"deploy -frontend", true},
+	}
+	fields := TaskFields(task)
+	for _, tt := range tests {
+		if got := mustParse(t, tt.query).Match(fields); got != tt.want {
+			t.Errorf("%q matches = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	q := mustParse(t, "deploy")
+	// listed from the most to the least relevant
+	tasks := []*models.Task{
+		{Title: "Deploy"},
+		{Title: "Misc", Tags: []string{"deploy"}},
+		{Title: "Deploy the new API"},
+		{Title: "Misc", Description: "deploy"},
+		{Title: "Misc", Description: "Remember to deploy once the tests pass on main"},
+		{Title: "Misc", Description: "nothing"},
+	}
+	scores := []float64{}
+	for _, task := range tasks {
+		scores = append(scores, q.Score(TaskFields(task)))
+	}
+	if !slices.IsSortedFunc(scores, func(a, b float64) int { return cmp.Compare(b, a) }) || scores[len(scores)-1] != 0 {
+		t.Errorf("scores %v are not in order of relevance", scores)
+	}
+	if s := mustParse(t, `"deploy the"`).Score(TaskFields(tasks[2])); s <= scores[2] {
+		t.Errorf("a two word phrase scores %v, one word %v", s, scores[2])
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		query, text, want string
+	}{
+		{"deploy", "Deploy <now>", "<mark>Deploy</mark> &lt;now&gt;"},
+		{"staging", "nothing here", ""},
+		{`"release notes" draft`, "Draft the release notes", "<mark>Draft</mark> the <mark>release notes</mark>"},
+		{"z", "a b c d e f g h z", "…c d e f g h <mark>z</mark>"},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.query).Highlight(tt.text); got != tt.want {
+			t.Errorf("%q in %q = %q, want %q", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"task-manager/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAssignees(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	bobCtx, bob := e.user("bob")
+	_, carol := e.user("carol")
+	task := e.task(ctx, "Ship")
+
+	if _, err := e.tasks.AssignTask(ctx, task.ID, "nobody@example.com", nil); code(err) != http.StatusNotFound {
+		t.Errorf("unknown user: %v", err)
+	}
+
+	assigned, err := e.tasks.AssignTask(ctx, task.ID, bob.Email, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(assigned.AssigneeIDs, []primitive.ObjectID{bob.ID}) || assigned.Version != task.Version+1 {
+		t.Errorf("assigned %+v", assigned)
+	}
+	again, err := e.tasks.AssignTask(ctx, task.ID, bob.Email, nil)
+	if err != nil || again.Version != assigned.Version {
+		t.Errorf("assigning twice: %+v, %v", again, err)
+	}
+	if !slices.Equal(e.sent, []string{bob.Email}) {
+		t.Errorf("sent %v", e.sent)
+	}
+
+	// assignees can see the task but only the owner may reassign it
+	if _, err := e.tasks.GetTask(bobCtx, task.ID); err != nil {
+		t.Errorf("assignee reading: %v", err)
+	}
+	if _, err := e.tasks.ReassignTask(bobCtx, task.ID, []string{bob.Email}, nil); code(err) != http.StatusUnauthorized {
+		t.Errorf("assignee reassigning: %v", err)
+	}
+
+	e.sent = nil
+	reassigned, err := e.tasks.ReassignTask(ctx, task.ID, []string{carol.Email, bob.Email, carol.Email}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(reassigned.AssigneeIDs, []primitive.ObjectID{carol.ID, bob.ID}) || !slices.Equal(e.sent, []string{carol.Email}) {
+		t.Errorf("assignees %v, sent %v", reassigned.AssigneeIDs, e.sent)
+	}
+
+	if _, err := e.tasks.UnassignTask(ctx, task.ID, primitive.NewObjectID(), nil); code(err) != http.StatusNotFound {
+		t.Errorf("unassigning someone not assigned: %v", err)
+	}
+	unassigned, err := e.tasks.UnassignTask(ctx, task.ID, carol.ID, nil)
+	if err != nil || !slices.Equal(unassigned.AssigneeIDs, []primitive.ObjectID{bob.ID}) {
+		t.Errorf("unassigned %+v, %v", unassigned, err)
+	}
+}
+
+// TestConcurrentAssign checks that assignments made at the same time are
+// all kept.
+func TestConcurrentAssign(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	task := e.task(ctx, "Ship")
+
+	var users []*models.User
+	for _, name := range []string{"bob", "carol", "dave"} {
+		_, user := e.user(name)
+		users = append(users, user)
+	}
+
+	var wg sync.WaitGroup
+	for _, user := range users {
+		wg.Go(func() {
+			if _, err := e.tasks.AssignTask(ctx, task.ID, user.Email, nil); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	got, err := e.tasks.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.AssigneeIDs) != len(users) || got.Version != task.Version+len(users) {
+		t.Errorf("assignees %v at version %d", got.AssigneeIDs, got.Version)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"task-manager/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBulkTasks(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	bobCtx, _ := e.user("bob")
+	a, b := e.task(ctx, "A"), e.task(ctx, "B")
+	theirs := e.task(bobCtx, "Theirs")
+
+	if _, err := e.tasks.BulkTasks(ctx, &models.BulkTaskRequest{IDs: []string{a.ID.Hex()}, Action: models.BulkStatus}); code(err) != http.StatusBadRequest {
+		t.Errorf("status without a status: %v", err)
+	}
+	if _, err := e.tasks.BulkTasks(ctx, &models.BulkTaskRequest{IDs: []string{a.ID.Hex()}, Action: "archive"}); code(err) != http.StatusBadRequest {
+		t.Errorf("unknown action: %v", err)
+	}
+
+	// each task succeeds or fails on its own and duplicates run once
+	ids := []string{a.ID.Hex(), "nope", theirs.ID.Hex(), b.ID.Hex(), a.ID.Hex(), primitive.NewObjectID().Hex()}
+	results, err := e.tasks.BulkTasks(ctx, &models.BulkTaskRequest{IDs: ids, Action: models.BulkAddTag, Tag: " Urgent "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.BulkResult{
+		{ID: a.ID.Hex(), Success: true},
+		{ID: "nope", Code: http.StatusBadRequest},
+		{ID: theirs.ID.Hex(), Code: http.StatusUnauthorized},
+		{ID: b.ID.Hex(), Success: true},
+		{ID: ids[5], Code: http.StatusNotFound},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("results %+v", results)
+	}
+	for i, w := range want {
+		if r := results[i]; r.ID != w.ID || r.Success != w.Success || r.Code != w.Code {
+			t.Errorf("result %d = %+v, want %+v", i, r, w)
+		}
+	}
+	got, _ := e.tasks.GetTask(ctx, b.ID)
+	if len(got.Tags) != 1 || got.Tags[0] != "urgent" {
+		t.Errorf("tags %v", got.Tags)
+	}
+	if got, _ := e.tasks.GetTask(bobCtx, theirs.ID); len(got.Tags) != 0 {
+		t.Errorf("someone else's task was tagged %v", got.Tags)
+	}
+
+	results, err = e.tasks.BulkTasks(ctx, &models.BulkTaskRequest{IDs: []string{a.ID.Hex(), b.ID.Hex()}, Action: models.BulkStatus, Status: "completed"})
+	if err != nil || !results[0].Success || !results[1].Success {
+		t.Fatalf("status %+v, %v", results, err)
+	}
+	if got, _ := e.tasks.GetTask(ctx, a.ID); got.Status != "completed" {
+		t.Errorf("status %q", got.Status)
+	}
+
+	if _, err := e.tasks.BulkTasks(ctx, &models.BulkTaskRequest{IDs: []string{a.ID.Hex(), b.ID.Hex()}, Action: models.BulkDelete}); err != nil {
+		t.Fatal(err)
+	}
+	if trash, _ := e.tasks.GetTrash(ctx); len(trash) != 2 {
+		t.Errorf("trash %v", titles(trash))
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+)
+
+// CalendarService publishes a user's dated tasks as a calendar feed.
+// Calendar apps cannot send a bearer token, so a feed is reached through a
+// secret token of its own that the user can rotate or revoke.
+type CalendarService struct {
+	Users repository.UserStore
+	Tasks *TaskService
+}
+
+func NewCalendarService(users repository.UserStore, tasks *TaskService) *CalendarService {
+	return &CalendarService{
+		Users: users,
+		Tasks: tasks,
+	}
+}
+
+// CreateFeedToken gives the caller a new feed token. Any earlier token
+// stops working.
+func (s *CalendarService) CreateFeedToken(ctx context.Context) (string, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", utils.Internal("Internal security error", nil)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := s.Users.SetCalendarToken(ctx, userObjId, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeFeedToken turns the caller's feed off.
+func (s *CalendarService) RevokeFeedToken(ctx context.Context) error {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Users.SetCalendarToken(ctx, userObjId, "")
+}
+
+// Feed hands every task with a due date that the token's owner can see to
+// emit, a page at a time, as ExportTasks does.
+func (s *CalendarService) Feed(ctx context.Context, token string, emit func([]models.Task) error) error {
+	if token == "" {
+		return utils.NotFound("Calendar not found", nil)
+	}
+	user, err := s.Users.GetUserByCalendarToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return s.Tasks.ExportTasks(WithUser(ctx, user), map[string]string{"q": "-due:none"}, emit)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	e := newEnv(t)
+	calendar := NewCalendarService(e.stores.Users, e.tasks)
+	ctx, _ := e.user("alice")
+	e.task(ctx, "Dated", func(r *models.CreateTaskRequest) {
+		r.DueDate = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	})
+	e.task(ctx, "Undated")
+
+	feed := func(token string) ([]string, error) {
+		got := []string{}
+		err := calendar.Feed(context.Background(), token, func(tasks []models.Task) error {
+			got = append(got, titles(tasks)...)
+			return nil
+		})
+		return got, err
+	}
+
+	if _, err := feed(""); code(err) != http.StatusNotFound {
+		t.Errorf("no token: %v", err)
+	}
+	first, err := calendar.CreateFeedToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := feed(first)
+	if err != nil || !slices.Equal(got, []string{"Dated"}) {
+		t.Errorf("feed %v, %v", got, err)
+	}
+
+	// a new token replaces the old one and revoking turns the feed off
+	second, err := calendar.CreateFeedToken(ctx)
+	if err != nil || second == first {
+		t.Fatalf("second token %q, %v", second, err)
+	}
+	if _, err := feed(first); code(err) != http.StatusNotFound {
+		t.Errorf("replaced token: %v", err)
+	}
+	if err := calendar.RevokeFeedToken(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feed(second); code(err) != http.StatusNotFound {
+		t.Errorf("revoked token: %v", err)
+	}
+}
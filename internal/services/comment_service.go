@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CommentService manages discussion threads on tasks. Access follows the
+// task: viewers may read, editors may comment, and only the author may edit.
+type CommentService struct {
+	Repo  repository.CommentStore
+	Tasks *TaskService
+}
+
+func NewCommentService(repo repository.CommentStore, tasks *TaskService) *CommentService {
+	return &CommentService{
+		Repo:  repo,
+		Tasks: tasks,
+	}
+}
+
+func (s *CommentService) GetComments(ctx context.Context, taskID primitive.ObjectID) ([]models.Comment, error) {
+	task, err := s.Tasks.taskWithRole(ctx, taskID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Repo.GetComments(ctx, task.ID)
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, taskID primitive.ObjectID, req *models.CommentRequest) (*models.Comment, error) {
+	task, err := s.Tasks.taskWithRole(ctx, taskID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	author, _ := ctx.Value("username").(string)
+
+	comment := &models.Comment{
+		TaskID:     task.ID,
+		AuthorID:   userObjId,
+		AuthorName: author,
+		Body:       req.Body,
+	}
+	err = s.Repo.CreateComment(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (s *CommentService) UpdateComment(ctx context.Context, taskID, id primitive.ObjectID, req *models.CommentRequest) (*models.Comment, error) {
+	comment, err := s.comment(ctx, taskID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != userObjId {
+		return nil, utils.Unauthorized("Only the author can edit a comment", nil)
+	}
+
+	comment.Body = req.Body
+	return s.Repo.UpdateComment(ctx, comment)
+}
+
+// DeleteComment removes a comment. Authors may delete their own comments and
+// task owners may delete any comment on the task.
+func (s *CommentService) DeleteComment(ctx context.Context, taskID, id primitive.ObjectID) error {
+	comment, err := s.comment(ctx, taskID, id)
+	if err != nil {
+		return err
+	}
+
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != userObjId {
+		if _, err := s.Tasks.taskWithRole(ctx, taskID, models.RoleOwner); err != nil {
+			return err
+		}
+	}
+
+	return s.Repo.DeleteComment(ctx, comment.ID)
+}
+
+// comment loads a comment on a task the caller can see.
+func (s *CommentService) comment(ctx context.Context, taskID, id primitive.ObjectID) (*models.Comment, error) {
+	task, err := s.Tasks.taskWithRole(ctx, taskID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.Repo.GetCommentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.TaskID != task.ID {
+		return nil, utils.NotFound("Comment not found", nil)
+	}
+	return comment, nil
+}
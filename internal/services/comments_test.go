@@ -0,0 +1,80 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"task-manager/internal/models"
+)
+
+func TestComments(t *testing.T) {
+	e := newEnv(t)
+	comments := NewCommentService(e.stores.Comments, e.tasks)
+	ctx, _ := e.user("alice")
+	bobCtx, bob := e.user("bob")
+	carolCtx, carol := e.user("carol")
+
+	project, err := e.projects.CreateProject(ctx, &models.CreateProjectRequest{Name: "Launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []struct {
+		user *models.User
+		role string
+	}{{bob, models.RoleEditor}, {carol, models.RoleViewer}} {
+		if _, err := e.projects.InviteMember(ctx, project.ID, &models.InviteMemberRequest{Email: m.user.Email, Role: m.role}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	task := e.task(ctx, "Announce", func(r *models.CreateTaskRequest) { r.ProjectID = project.ID.Hex() })
+	other := e.task(ctx, "Other")
+
+	comment, err := comments.CreateComment(bobCtx, task.ID, &models.CommentRequest{Body: "Draft is ready"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.AuthorID != bob.ID || comment.AuthorName != "bob" {
+		t.Errorf("comment %+v", comment)
+	}
+	if _, err := comments.CreateComment(carolCtx, task.ID, &models.CommentRequest{Body: "Me too"}); code(err) != http.StatusUnauthorized {
+		t.Errorf("a viewer commenting: %v", err)
+	}
+
+	list, err := comments.GetComments(carolCtx, task.ID)
+	if err != nil || len(list) != 1 || list[0].Body != "Draft is ready" {
+		t.Errorf("comments %+v, %v", list, err)
+	}
+
+	// only the author edits
+	if _, err := comments.UpdateComment(ctx, task.ID, comment.ID, &models.CommentRequest{Body: "Edited"}); code(err) != http.StatusUnauthorized {
+		t.Errorf("the task owner editing: %v", err)
+	}
+	edited, err := comments.UpdateComment(bobCtx, task.ID, comment.ID, &models.CommentRequest{Body: "Final draft is ready"})
+	if err != nil || edited.Body != "Final draft is ready" {
+		t.Errorf("edited %+v, %v", edited, err)
+	}
+
+	// a comment is only found through its own task
+	if _, err := comments.GetComments(bobCtx, other.ID); code(err) != http.StatusUnauthorized {
+		t.Errorf("reading comments on a task not shared: %v", err)
+	}
+	if err := comments.DeleteComment(ctx, other.ID, comment.ID); code(err) != http.StatusNotFound {
+		t.Errorf("deleting through another task: %v", err)
+	}
+
+	// the author or the task owner deletes
+	mine, err := comments.CreateComment(ctx, task.ID, &models.CommentRequest{Body: "Thanks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := comments.DeleteComment(bobCtx, task.ID, mine.ID); code(err) != http.StatusUnauthorized {
+		t.Errorf("an editor deleting someone else's comment: %v", err)
+	}
+	if err := comments.DeleteComment(ctx, task.ID, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = comments.GetComments(ctx, task.ID)
+	if len(list) != 1 || list[0].ID != mine.ID {
+		t.Errorf("comments after delete %+v", list)
+	}
+}
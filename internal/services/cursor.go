@@ -0,0 +1,236 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// cursor directions
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// taskCursor marks a position in a sorted task list: the sort key values and
+// _id of the task it points at. It travels to clients as opaque base64url
+// bson so values keep their types.
+type taskCursor struct {
+	Sort   string          `bson:"s"`
+	Values []bson.RawValue `bson:"v"`
+	Dir    string          `bson:"d"`
+}
+
+// sortSpec describes sort as a string so a cursor can only be reused with
+// the sort it was issued for.
+func sortSpec(sort bson.D) string {
+	parts := make([]string, len(sort))
+	for i, e := range sort {
+		parts[i] = fmt.Sprintf("%s:%v", e.Key, e.Value)
+	}
+	return strings.Join(parts, ",")
+}
+
+// encodeCursor returns a cursor pointing at task under sort, which must end
+// with _id.
+func encodeCursor(task *models.Task, sort bson.D, dir string) (string, error) {
+	raw, err := bson.Marshal(task)
+	if err != nil {
+		return "", err
+	}
+
+	c := taskCursor{Sort: sortSpec(sort), Dir: dir}
+	for _, e := range sort {
+		// computed keys are not part of the stored task
+		var computed any
+		switch e.Key {
+		case relevanceKey:
+			computed = task.Score
+		case repository.NoDueDateKey:
+			computed = noDueDate(task)
+		}
+		if computed != nil {
+			t, data, err := bson.MarshalValue(computed)
+			if err != nil {
+				return "", err
+			}
+			c.Values = append(c.Values, bson.RawValue{Type: t, Value: data})
+			continue
+		}
+		v, err := bson.Raw(raw).LookupErr(e.Key)
+		if err != nil {
+			// a missing field sorts like null
+			v = bson.RawValue{Type: bson.TypeNull}
+		}
+		c.Values = append(c.Values, v)
+	}
+
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s string, sort bson.D) (*taskCursor, error) {
+	invalid := utils.BadRequest("Invalid cursor", nil)
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	var c taskCursor
+	if err := bson.Unmarshal(data, &c); err != nil {
+		return nil, invalid
+	}
+	if c.Dir != cursorNext && c.Dir != cursorPrev || len(c.Values) != len(sort) {
+		return nil, invalid
+	}
+	if c.Sort != sortSpec(sort) {
+		return nil, utils.BadRequest("Cursor was issued for a different sort", nil)
+	}
+	return &c, nil
+}
+
+// reverseSort flips every direction in sort, which is how a prev cursor
+// walks backwards.
+func reverseSort(sort bson.D) bson.D {
+	reversed := make(bson.D, len(sort))
+	for i, e := range sort {
+		reversed[i] = bson.E{Key: e.Key, Value: -e.Value.(int)}
+	}
+	return reversed
+}
+
+// afterFilter matches the tasks that come strictly after values in sort
+// order. Null and missing values sort before everything else, as in
+// MongoDB, and are matched explicitly because comparison operators never
+// cross types. The computed repository.NoDueDateKey becomes conditions on
+// due_date.
+func afterFilter(sort bson.D, values []bson.RawValue) bson.M {
+	clauses := []bson.M{}
+	for i, e := range sort {
+		var beyond bson.M
+		if e.Value.(int) > 0 {
+			beyond = greaterThan(e.Key, values[i])
+		} else {
+			beyond = lessThan(e.Key, values[i])
+		}
+		if beyond == nil {
+			continue
+		}
+
+		clause := []bson.M{}
+		for j := range i {
+			clause = append(clause, equalTo(sort[j].Key, values[j]))
+		}
+		clauses = append(clauses, bson.M{"$and": append(clause, beyond)})
+	}
+	return bson.M{"$or": clauses}
+}
+
+func isNull(v bson.RawValue) bool {
+	return v.Type == bson.TypeNull || v.Type == bson.TypeUndefined || v.Type == 0
+}
+
+func equalTo(key string, v bson.RawValue) bson.M {
+	if key == repository.NoDueDateKey {
+		return dueDateFilter(without(v))
+	}
+	if isNull(v) {
+		return bson.M{key: nil}
+	}
+	return bson.M{key: v}
+}
+
+// greaterThan and lessThan return nil when nothing can sort beyond v.
+func greaterThan(key string, v bson.RawValue) bson.M {
+	if key == repository.NoDueDateKey {
+		if without(v) {
+			return nil
+		}
+		return dueDateFilter(true)
+	}
+	if isNull(v) {
+		return bson.M{key: bson.M{"$ne": nil}}
+	}
+	return bson.M{key: bson.M{"$gt": v}}
+}
+
+func lessThan(key string, v bson.RawValue) bson.M {
+	if key == repository.NoDueDateKey {
+		if !without(v) {
+			return nil
+		}
+		return dueDateFilter(false)
+	}
+	if isNull(v) {
+		return nil
+	}
+	return bson.M{"$or": []bson.M{{key: bson.M{"$lt": v}}, {key: nil}}}
+}
+
+// noDueDate is the value of repository.NoDueDateKey for task.
+func noDueDate(task *models.Task) int32 {
+	if task.DueDate.IsZero() {
+		return 1
+	}
+	return 0
+}
+
+// without reports whether v, a repository.NoDueDateKey value, stands for a
+// task without a due date.
+func without(v bson.RawValue) bool {
+	n, _ := v.Int32OK()
+	return n == 1
+}
+
+// dueDateFilter matches the tasks without a due date, or with one.
+func dueDateFilter(without bool) bson.M {
+	if without {
+		return bson.M{"$or": []bson.M{{"due_date": nil}, {"due_date": time.Time{}}}}
+	}
+	return bson.M{"due_date": bson.M{"$gt": time.Time{}}}
+}
+
+// pageCursors builds the cursors around a fetched page. tasks is in display
+// order; hasMore reports whether the query found tasks beyond the page in
+// the direction it was walking.
+func pageCursors(tasks []models.Task, sort bson.D, dir string, hasMore, fromCursor bool) (next, prev string, err error) {
+	if len(tasks) == 0 {
+		return "", "", nil
+	}
+	first, last := &tasks[0], &tasks[len(tasks)-1]
+
+	moreAfter := hasMore
+	moreBefore := fromCursor
+	if dir == cursorPrev {
+		moreAfter, moreBefore = fromCursor, hasMore
+	}
+
+	if moreAfter {
+		next, err = encodeCursor(last, sort, cursorNext)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if moreBefore {
+		prev, err = encodeCursor(first, sort, cursorPrev)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return next, prev, nil
+}
+
+// hasSortKey reports whether sort already orders by key.
+func hasSortKey(sort bson.D, key string) bool {
+	return slices.ContainsFunc(sort, func(e bson.E) bool { return e.Key == key })
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	due := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	task := &models.Task{ID: primitive.NewObjectID(), Priority: 3, Title: "a", DueDate: due, Score: 1.5}
+	sort := bson.D{{Key: "priority", Value: -1}, {Key: "due_date", Value: 1}, {Key: relevanceKey, Value: -1}, {Key: "_id", Value: 1}}
+
+	s, err := encodeCursor(task, sort, cursorPrev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := decodeCursor(s, sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Dir != cursorPrev || len(c.Values) != len(sort) {
+		t.Fatalf("decoded %+v", c)
+	}
+	if c.Values[0].AsInt64() != 3 || c.Values[1].Time().UTC() != due || c.Values[2].Double() != 1.5 {
+		t.Errorf("values %v", c.Values)
+	}
+	var id primitive.ObjectID
+	if err := c.Values[3].Unmarshal(&id); err != nil || id != task.ID {
+		t.Errorf("id %v, %v", id, err)
+	}
+
+	if _, err := decodeCursor(s, sort[1:]); err == nil {
+		t.Error("cursor accepted for another sort")
+	}
+	for _, bad := range []string{"", "not base64!", "aGVsbG8"} {
+		if _, err := decodeCursor(bad, sort); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+// TestCursorPaging walks a task list page by page in both directions and
+// checks that every task shows up once, in order, including the tasks that
+// share a sort value or have none.
+func TestCursorPaging(t *testing.T) {
+	e := newEnv(t)
+	s := e.tasks
+	ctx, _ := e.user("alice")
+
+	for i := range 11 {
+		req := &models.CreateTaskRequest{
+			Title:       fmt.Sprintf("Task %02d", i),
+			Description: "d",
+			Category:    "work",
+			Priority:    i%3 + 1,
+			Status:      "pending",
+		}
+		if i%4 != 0 {
+			req.DueDate = time.Date(2026, 11, i%5+1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+		}
+		if _, err := s.CreateTask(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, sort := range []string{"priority", "due_date", "-due_date", "title"} {
+		t.Run(sort, func(t *testing.T) {
+			filters := map[string]string{"sort": sort, "limit": "100"}
+			all, err := s.GetTasks(ctx, filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := titles(all.Tasks)
+
+			filters["limit"] = "3"
+			forward := []string{}
+			var last *models.TaskPage
+			for page, cursor := 0, ""; page == 0 || cursor != ""; page++ {
+				filters["cursor"] = cursor
+				last, err = s.GetTasks(ctx, filters)
+				if err != nil {
+					t.Fatal(err)
+				}
+				forward = append(forward, titles(last.Tasks)...)
+				cursor = last.NextCursor
+			}
+			if !slices.Equal(forward, want) {
+				t.Fatalf("forward %v, want %v", forward, want)
+			}
+
+			backward := titles(last.Tasks)
+			for cursor := last.PrevCursor; cursor != ""; {
+				filters["cursor"] = cursor
+				page, err := s.GetTasks(ctx, filters)
+				if err != nil {
+					t.Fatal(err)
+				}
+				backward = append(titles(page.Tasks), backward...)
+				cursor = page.PrevCursor
+			}
+			if !slices.Equal(backward, want) {
+				t.Errorf("backward %v, want %v", backward, want)
+			}
+		})
+	}
+}
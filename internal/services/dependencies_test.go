@@ -0,0 +1,119 @@
+package services
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"task-manager/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAddDependencyCycles(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	a, b, c := e.task(ctx, "Step A"), e.task(ctx, "Step B"), e.task(ctx, "Step C")
+
+	if _, err := e.tasks.AddDependency(ctx, b.ID, a.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.tasks.AddDependency(ctx, c.ID, b.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		task, blocker *models.Task
+		want          int
+	}{
+		{"self", a, a, http.StatusBadRequest},
+		{"two step cycle", a, b, http.StatusConflict},
+		{"three step cycle", a, c, http.StatusConflict},
+		{"existing edge", c, b, 0},
+		{"shortcut", c, a, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := e.tasks.AddDependency(ctx, tt.task.ID, tt.blocker.ID, nil); code(err) != tt.want {
+				t.Errorf("got %v, want status %d", err, tt.want)
+			}
+		})
+	}
+
+	got, err := e.tasks.GetTask(ctx, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.BlockedBy, []primitive.ObjectID{b.ID, a.ID}) {
+		t.Errorf("blocked by %v", got.BlockedBy)
+	}
+
+	bob, _ := e.user("bob")
+	other := e.task(bob, "Bob's task")
+	if _, err := e.tasks.AddDependency(ctx, a.ID, other.ID, nil); code(err) != http.StatusUnauthorized {
+		t.Errorf("blocker of another user: %v", err)
+	}
+}
+
+func TestCompleteBlockedTask(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	blocker, task := e.task(ctx, "Blocker"), e.task(ctx, "Blocked")
+	if _, err := e.tasks.AddDependency(ctx, task.ID, blocker.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	completed := "completed"
+	complete := models.UpdateTaskRequest{Status: &completed}
+	if _, err := e.tasks.UpdateTask(ctx, task.ID, complete, nil); code(err) != http.StatusConflict {
+		t.Fatalf("completing a blocked task: %v", err)
+	}
+
+	// a trashed blocker no longer blocks
+	if err := e.tasks.DeleteTask(ctx, blocker.ID, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.tasks.UpdateTask(ctx, task.ID, complete, nil); err != nil {
+		t.Fatalf("completing once the blocker is trashed: %v", err)
+	}
+}
+
+func TestBlockedFilter(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	open, done, free := e.task(ctx, "Open blocker"), e.task(ctx, "Done blocker"), e.task(ctx, "Free")
+	blocked, unblocked := e.task(ctx, "Blocked"), e.task(ctx, "Unblocked")
+
+	if _, err := e.tasks.AddDependency(ctx, blocked.ID, open.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.tasks.AddDependency(ctx, unblocked.ID, done.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	completed := "completed"
+	if _, err := e.tasks.UpdateTask(ctx, done.ID, models.UpdateTaskRequest{Status: &completed}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"true", []string{blocked.Title}},
+		{"1", []string{blocked.Title}},
+		{"false", []string{done.Title, free.Title, open.Title, unblocked.Title}},
+	}
+	for _, tt := range tests {
+		page, err := e.tasks.GetTasks(ctx, map[string]string{"blocked": tt.value, "sort": "title"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(page.Tasks); !slices.Equal(got, tt.want) {
+			t.Errorf("blocked=%s: %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := e.tasks.GetTasks(ctx, map[string]string{"blocked": "yes"}); code(err) != http.StatusBadRequest {
+		t.Errorf("blocked=yes: %v", err)
+	}
+}
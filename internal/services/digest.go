@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// DigestGrace is how late a digest may still go out after its send time,
+// for when the server was down at the time.
+const DigestGrace = time.Hour
+
+// digestItems is how many tasks a digest lists in each section.
+const digestItems = 50
+
+// DigestService emails users who opted in a summary of their overdue tasks,
+// tasks due today and tasks completed in the last week. Like reminders,
+// each digest is claimed in the NotificationStore before it is sent.
+type DigestService struct {
+	Tasks         repository.TaskStore
+	Users         repository.UserStore
+	Notifications repository.NotificationStore
+	// Send delivers a digest, by email unless replaced
+	Send func(email string, subject string, digest *utils.Digest) error
+}
+
+func NewDigestService(tasks repository.TaskStore, users repository.UserStore, notifications repository.NotificationStore) *DigestService {
+	return &DigestService{
+		Tasks:         tasks,
+		Users:         users,
+		Notifications: notifications,
+		Send:          utils.SendDigestEmail,
+	}
+}
+
+// SendDigests sends the digest of every subscriber whose send time, in
+// their own time zone, passed within the last DigestGrace. Each user gets
+// at most one digest per local day, and none when there is nothing to list.
+// It returns how many digests were sent; one that fails to send is tried
+// again on the next run.
+func (s *DigestService) SendDigests(ctx context.Context, now time.Time) (int, error) {
+	users, err := s.Users.GetDigestSubscribers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range users {
+		// stop between users when the server shuts down
+		if ctx.Err() != nil {
+			break
+		}
+		user := &users[i]
+		at, ok := digestDue(user.Settings, now)
+		if !ok {
+			continue
+		}
+
+		ok, err := s.digest(ctx, user, at, now)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// digest claims and sends user's digest for the send time at.
+func (s *DigestService) digest(ctx context.Context, user *models.User, at, now time.Time) (bool, error) {
+	n := &models.Notification{
+		ID:     fmt.Sprintf("%s:%s:%s", models.NotificationDigest, user.ID.Hex(), at.Format(time.DateOnly)),
+		Kind:   models.NotificationDigest,
+		UserID: user.ID,
+	}
+	claimed, err := s.Notifications.ClaimNotification(ctx, n)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	digest, err := s.buildDigest(ctx, user, now)
+	if err != nil {
+		if releaseErr := s.Notifications.ReleaseNotification(ctx, n.ID); releaseErr != nil {
+			return false, releaseErr
+		}
+		return false, err
+	}
+	// the claim is kept, so an empty digest is not looked at again today
+	counts := []string{}
+	for _, section := range digest.Sections {
+		if section.Total > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", section.Total, strings.ToLower(section.Name)))
+		}
+	}
+	if len(counts) == 0 {
+		return false, nil
+	}
+
+	subject := fmt.Sprintf("Your %s task digest: %s", digest.Frequency, strings.Join(counts, ", "))
+	if err := s.Send(user.Email, subject, digest); err != nil {
+		log.Println("Error sending digest:", err)
+		return false, s.Notifications.ReleaseNotification(ctx, n.ID)
+	}
+	return true, nil
+}
+
+// buildDigest lists the open tasks user owns or is assigned that were due
+// before today or are due today, in their time zone, and those completed in
+// the last seven days.
+func (s *DigestService) buildDigest(ctx context.Context, user *models.User, now time.Time) (*utils.Digest, error) {
+	settings := user.Settings.WithDefaults()
+	loc := user.Settings.Location()
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	mine := func(filter bson.M) bson.M {
+		filter["deleted_at"] = nil
+		filter["$or"] = []bson.M{
+			{"user_id": user.ID},
+			{"assignee_ids": user.ID},
+		}
+		return filter
+	}
+	byDue := bson.D{{Key: "due_date", Value: 1}, {Key: "priority", Value: -1}}
+
+	sections := []struct {
+		name   string
+		filter bson.M
+		sort   bson.D
+	}{
+		{"Overdue", mine(bson.M{
+			"status":   bson.M{"$ne": "completed"},
+			"due_date": bson.M{"$gt": time.Time{}, "$lt": today},
+		}), byDue},
+		{"Due today", mine(bson.M{
+			"status":   bson.M{"$ne": "completed"},
+			"due_date": bson.M{"$gte": today, "$lt": tomorrow},
+		}), byDue},
+		{"Completed this week", mine(bson.M{
+			"status":       "completed",
+			"completed_at": bson.M{"$gte": now.AddDate(0, 0, -7)},
+		}), bson.D{{Key: "completed_at", Value: -1}}},
+	}
+
+	digest := &utils.Digest{
+		Username:  user.Username,
+		Frequency: settings.DigestFrequency,
+		Date:      local.Format("Monday, 2 January 2006"),
+	}
+	for _, section := range sections {
+		tasks, err := s.Tasks.GetTasks(ctx, section.filter, section.sort, digestItems, 0)
+		if err != nil {
+			return nil, utils.Internal("Error getting tasks", nil)
+		}
+		total := len(tasks)
+		if total == digestItems {
+			count, err := s.Tasks.CountTasks(ctx, section.filter)
+			if err != nil {
+				return nil, err
+			}
+			total = int(count)
+		}
+
+		items := make([]utils.DigestItem, len(tasks))
+		for i, task := range tasks {
+			items[i] = utils.DigestItem{Title: task.Title, Priority: task.Priority}
+			if !task.DueDate.IsZero() {
+				items[i].Due = digestDueDate(task.DueDate, loc)
+			}
+		}
+		digest.Sections = append(digest.Sections, utils.DigestSection{
+			Name:  section.name,
+			Items: items,
+			Total: total,
+			More:  total - len(items),
+		})
+	}
+	return digest, nil
+}
+
+// RunDigests calls SendDigests every interval until ctx is cancelled.
+func (s *DigestService) RunDigests(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.SendDigests(ctx, time.Now())
+		if err != nil {
+			log.Println("Error sending digests:", err)
+		} else if n > 0 {
+			log.Printf("Sent %d task digests\n", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// digestDue returns the latest send time the settings call for, in the
+// user's time zone, and whether it passed within the last DigestGrace.
+func digestDue(settings models.UserSettings, now time.Time) (time.Time, bool) {
+	settings = settings.WithDefaults()
+	clock, err := time.Parse("15:04", settings.DigestTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := now.In(settings.Location())
+	at := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, local.Location())
+	if at.After(local) {
+		at = at.AddDate(0, 0, -1)
+	}
+	if settings.DigestFrequency == models.DigestWeekly && !strings.EqualFold(at.Weekday().String(), settings.DigestWeekday) {
+		return time.Time{}, false
+	}
+	return at, local.Sub(at) <= DigestGrace
+}
+
+// digestDueDate formats a due date in loc, leaving out the time of the
+// all-day tasks that are due at midnight UTC.
+func digestDueDate(due time.Time, loc *time.Location) string {
+	if due.UTC().Truncate(24 * time.Hour).Equal(due.UTC()) {
+		return due.UTC().Format("Mon 2 Jan")
+	}
+	return due.In(loc).Format("Mon 2 Jan 15:04")
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+)
+
+func TestDigestDue(t *testing.T) {
+	// Monday 19 October 2026, when Berlin is two hours ahead of UTC
+	monday := func(hour, min int) time.Time { return time.Date(2026, 10, 19, hour, min, 0, 0, time.UTC) }
+	daily := models.UserSettings{DigestFrequency: models.DigestDaily, DigestTime: "08:00"}
+	weekly := func(day string) models.UserSettings {
+		return models.UserSettings{DigestFrequency: models.DigestWeekly, DigestTime: "08:00", DigestWeekday: day}
+	}
+	berlin := daily
+	berlin.Timezone = "Europe/Berlin"
+
+	tests := []struct {
+		name     string
+		settings models.UserSettings
+		now      time.Time
+		at       time.Time
+		due      bool
+	}{
+		{"at the send time", daily, monday(8, 0), monday(8, 0), true},
+		{"within the grace", daily, monday(9, 0), monday(8, 0), true},
+		{"past the grace", daily, monday(9, 1), monday(8, 0), false},
+		{"before the send time", daily, monday(7, 59), monday(8, 0).AddDate(0, 0, -1), false},
+		{"on the weekday", weekly("monday"), monday(8, 30), monday(8, 0), true},
+		{"on another day", weekly("tuesday"), monday(8, 30), time.Time{}, false},
+		{"in the user's time zone", berlin, monday(6, 30), monday(6, 0), true},
+		{"default time", models.UserSettings{DigestFrequency: models.DigestDaily}, monday(8, 10), monday(8, 0), true},
+	}
+	for _, tt := range tests {
+		at, due := digestDue(tt.settings, tt.now)
+		if due != tt.due || (!tt.at.IsZero() && !at.Equal(tt.at)) {
+			t.Errorf("%s: %v, %v", tt.name, at, due)
+		}
+	}
+}
+
+func TestSendDigests(t *testing.T) {
+	e := newEnv(t)
+	digests := NewDigestService(e.stores.Tasks, e.stores.Users, e.stores.Notifications)
+	var subjects []string
+	var last *utils.Digest
+	var sendErr error
+	digests.Send = func(email, subject string, digest *utils.Digest) error {
+		if sendErr != nil {
+			return sendErr
+		}
+		subjects = append(subjects, email+": "+subject)
+		last = digest
+		return nil
+	}
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	subscribe := func(name string) context.Context {
+		ctx, user := e.user(name)
+		settings := models.UserSettings{DigestFrequency: models.DigestDaily, DigestTime: now.Format("15:04")}
+		if err := e.stores.Users.UpdateSettings(context.Background(), user.ID, settings); err != nil {
+			t.Fatal(err)
+		}
+		return ctx
+	}
+	ctx := subscribe("alice")
+	subscribe("bob")
+	e.user("carol")
+
+	today := now.Truncate(24 * time.Hour)
+	due := func(d time.Time) func(*models.CreateTaskRequest) {
+		return func(r *models.CreateTaskRequest) { r.DueDate = d.Format(time.RFC3339) }
+	}
+	e.task(ctx, "Late", due(today.AddDate(0, 0, -1)))
+	e.task(ctx, "Also late", due(today.AddDate(0, 0, -2)))
+	e.task(ctx, "Now", due(today))
+	e.task(ctx, "Next week", due(today.AddDate(0, 0, 7)))
+	e.task(ctx, "Finished", func(r *models.CreateTaskRequest) { r.Status = "completed" })
+
+	sendErr = errors.New("mail is down")
+	if n, err := digests.SendDigests(context.Background(), now); err != nil || n != 0 {
+		t.Fatalf("failing run sent %d, %v", n, err)
+	}
+	sendErr = nil
+
+	// bob has nothing to hear about and carol has not subscribed
+	n, err := digests.SendDigests(context.Background(), now)
+	if err != nil || n != 1 {
+		t.Fatalf("sent %d, %v", n, err)
+	}
+	want := "alice@example.com: Your daily task digest: 2 overdue, 1 due today, 1 completed this week"
+	if len(subjects) != 1 || subjects[0] != want {
+		t.Errorf("subjects %q", subjects)
+	}
+	if s := last.Sections[0]; len(s.Items) != 2 || s.Items[0].Title != "Also late" || s.More != 0 {
+		t.Errorf("overdue section %+v", s)
+	}
+
+	if n, err := digests.SendDigests(context.Background(), now.Add(time.Minute)); err != nil || n != 0 {
+		t.Errorf("second run sent %d, %v", n, err)
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+	"slices"
+
+	"task-manager/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fields left out of history diffs: bookkeeping and values computed on read
+var untrackedFields = []string{"_id", "created_at", "updated_at", "version", "progress", "subtasks", "comment_count"}
+
+// GetHistory lists the recorded changes to a task, oldest first. History
+// stays readable while the task is in the trash.
+func (s *TaskService) GetHistory(ctx context.Context, id primitive.ObjectID) ([]models.HistoryEntry, error) {
+	task, err := s.anyTaskWithRole(ctx, id, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.History.GetHistory(ctx, task.ID)
+}
+
+// recordHistory appends an entry describing the change from before to after.
+// before is nil for created tasks and after is nil for deleted ones. Updates
+// that change nothing are not recorded. The change itself is already stored
+// by then, so a failure is logged instead of failing the request.
+func (s *TaskService) recordHistory(ctx context.Context, action string, before, after *models.Task) {
+	changes, err := diffTasks(before, after)
+	if err != nil {
+		log.Println("Error recording task history:", err)
+		return
+	}
+	if action == models.ActionUpdated && len(changes) == 0 {
+		return
+	}
+
+	actorID, err := currentUserID(ctx)
+	if err != nil {
+		log.Println("Error recording task history:", err)
+		return
+	}
+	actorName, _ := ctx.Value("username").(string)
+
+	taskID := primitive.NilObjectID
+	if after != nil {
+		taskID = after.ID
+	} else if before != nil {
+		taskID = before.ID
+	}
+
+	err = s.History.AppendHistory(ctx, &models.HistoryEntry{
+		TaskID:    taskID,
+		ActorID:   actorID,
+		ActorName: actorName,
+		Action:    action,
+		Changes:   changes,
+	})
+	if err != nil {
+		log.Println("Error recording task history:", err)
+	}
+}
+
+// diffTasks compares the JSON form of two tasks field by field, so the
+// recorded values read the same as the API responses.
+func diffTasks(before, after *models.Task) ([]models.FieldChange, error) {
+	old, err := taskFields(before)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := taskFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range cur {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	changes := []models.FieldChange{}
+	for _, k := range keys {
+		if slices.Contains(untrackedFields, k) || reflect.DeepEqual(old[k], cur[k]) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: k, Before: old[k], After: cur[k]})
+	}
+	return changes, nil
+}
+
+func taskFields(task *models.Task) (map[string]any, error) {
+	fields := map[string]any{}
+	if task == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// cloneTask copies a task deeply enough that mutating the copy's slices
+// leaves the original untouched.
+func cloneTask(task *models.Task) *models.Task {
+	c := *task
+	c.BlockedBy = slices.Clone(task.BlockedBy)
+	c.AssigneeIDs = slices.Clone(task.AssigneeIDs)
+	c.Tags = slices.Clone(task.Tags)
+	return &c
+}
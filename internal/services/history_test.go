@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+)
+
+// brokenHistory fails every write, as a history store that is down would.
+type brokenHistory struct {
+	repository.HistoryStore
+}
+
+func (brokenHistory) AppendHistory(ctx context.Context, entry *models.HistoryEntry) error {
+	return errors.New("history is down")
+}
+
+func actions(entries []models.HistoryEntry) []string {
+	out := []string{}
+	for _, e := range entries {
+		out = append(out, e.Action)
+	}
+	return out
+}
+
+func TestHistory(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	task := e.task(ctx, "Write")
+
+	title := "Rewrite"
+	if _, err := e.tasks.UpdateTask(ctx, task.ID, models.UpdateTaskRequest{Title: &title}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.tasks.AddTags(ctx, task.ID, []string{"docs"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.tasks.DeleteTask(ctx, task.ID, false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := e.tasks.GetHistory(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := actions(history); !slices.Equal(got, []string{models.ActionCreated, models.ActionUpdated, models.ActionUpdated, models.ActionDeleted}) {
+		t.Fatalf("actions %v", got)
+	}
+	if c := history[1].Changes; len(c) != 1 || c[0].Field != "title" || c[0].Before != "Write" || c[0].After != "Rewrite" || history[1].ActorName != "alice" {
+		t.Errorf("update entry %+v", history[1])
+	}
+}
+
+func TestDeleteProjectRecordsMoves(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	project, err := e.projects.CreateProject(ctx, &models.CreateProjectRequest{Name: "Launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := e.task(ctx, "Announce", func(r *models.CreateTaskRequest) { r.ProjectID = project.ID.Hex() })
+	if err := e.projects.DeleteProject(ctx, project.ID, "move"); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := e.tasks.GetHistory(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Action != models.ActionUpdated {
+		t.Fatalf("history %+v", history)
+	}
+	if c := history[1].Changes; len(c) != 1 || c[0].Field != "project_id" || c[0].Before != project.ID.Hex() {
+		t.Errorf("move entry %+v", c)
+	}
+}
+
+// TestHistoryFailure checks that a change which is stored stays successful
+// when its history cannot be written.
+func TestHistoryFailure(t *testing.T) {
+	e := newEnv(t)
+	e.tasks.History = brokenHistory{e.stores.History}
+	ctx, _ := e.user("alice")
+	task := e.task(ctx, "Write")
+
+	title := "Rewrite"
+	updated, err := e.tasks.UpdateTask(ctx, task.ID, models.UpdateTaskRequest{Title: &title}, nil)
+	if err != nil || updated.Title != title {
+		t.Fatalf("updated %+v, %v", updated, err)
+	}
+	if err := e.tasks.DeleteTask(ctx, task.ID, false, nil); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"task-manager/internal/models"
+)
+
+func TestInviteMember(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	bobCtx, bob := e.user("bob")
+	project, err := e.projects.CreateProject(ctx, &models.CreateProjectRequest{Name: "Launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.projects.InviteMember(ctx, project.ID, &models.InviteMemberRequest{Email: "nobody@example.com", Role: models.RoleViewer}); code(err) != http.StatusNotFound {
+		t.Errorf("unknown user: %v", err)
+	}
+	if _, err := e.projects.GetProject(bobCtx, project.ID); code(err) != http.StatusUnauthorized {
+		t.Errorf("before the invitation: %v", err)
+	}
+
+	// a failed email is logged, the member is still added
+	e.sendErr = errors.New("mail is down")
+	invited, err := e.projects.InviteMember(ctx, project.ID, &models.InviteMemberRequest{Email: bob.Email, Role: models.RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invited.Members) != 1 || invited.Members[0].UserID != bob.ID || !slices.Equal(e.sent, []string{bob.Email}) {
+		t.Errorf("members %+v, sent %v", invited.Members, e.sent)
+	}
+	if _, err := e.projects.GetProject(bobCtx, project.ID); err != nil {
+		t.Errorf("after the invitation: %v", err)
+	}
+	if _, err := e.projects.InviteMember(bobCtx, project.ID, &models.InviteMemberRequest{Email: "alice@example.com", Role: models.RoleViewer}); code(err) != http.StatusUnauthorized {
+		t.Errorf("a viewer inviting: %v", err)
+	}
+
+	e.sendErr = nil
+
+	// inviting again changes the role
+	invited, err = e.projects.InviteMember(ctx, project.ID, &models.InviteMemberRequest{Email: bob.Email, Role: models.RoleEditor})
+	if err != nil || len(invited.Members) != 1 || invited.Members[0].Role != models.RoleEditor {
+		t.Fatalf("invited again %+v, %v", invited, err)
+	}
+
+	if err := e.projects.RemoveMember(bobCtx, project.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.projects.GetProject(bobCtx, project.ID); code(err) != http.StatusUnauthorized {
+		t.Errorf("after leaving: %v", err)
+	}
+}
+
+func TestUpdateProjectVersion(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	project, err := e.projects.CreateProject(ctx, &models.CreateProjectRequest{Name: "Launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "Relaunch"
+	updated, err := e.projects.UpdateProject(ctx, project.ID, models.UpdateProjectRequest{Name: &name})
+	if err != nil || updated.Name != name || updated.Version != project.Version+1 {
+		t.Fatalf("updated %+v, %v", updated, err)
+	}
+
+	// a write based on the old version is refused
+	project.Name = "Stale"
+	if _, err := e.stores.Projects.UpdateProject(ctx, project); code(err) != http.StatusPreconditionFailed {
+		t.Errorf("stale update: %v", err)
+	}
+	got, _ := e.projects.GetProject(ctx, project.ID)
+	if got.Name != name {
+		t.Errorf("name %q", got.Name)
+	}
+}
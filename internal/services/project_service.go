@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+	"task-manager/internal/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ProjectService struct {
+	Repo  repository.ProjectStore
+	Tasks *TaskService
+	Users repository.UserStore
+	// SendInvitation tells a new member about the project, by email unless
+	// replaced
+	SendInvitation func(email string, inviter string, projectName string, role string) error
+}
+
+func NewProjectService(repo repository.ProjectStore, tasks *TaskService, users repository.UserStore) *ProjectService {
+	return &ProjectService{
+		Repo:           repo,
+		Tasks:          tasks,
+		Users:          users,
+		SendInvitation: utils.SendProjectInvitationEmail,
+	}
+}
+
+func (s *ProjectService) CreateProject(ctx context.Context, req *models.CreateProjectRequest) (*models.Project, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &models.Project{
+		OwnerID: userObjId,
+		Name:    req.Name,
+		Color:   req.Color,
+	}
+	err = s.Repo.CreateProject(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// GetProjects lists the projects the caller owns or is a member of, creating
+// the inbox on first use.
+func (s *ProjectService) GetProjects(ctx context.Context, includeArchived bool) ([]models.Project, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.inbox(ctx, userObjId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"$or": []bson.M{
+		{"owner_id": userObjId},
+		{"members.user_id": userObjId},
+	}}
+	if !includeArchived {
+		filter["archived"] = false
+	}
+	return s.Repo.GetProjects(ctx, filter)
+}
+
+func (s *ProjectService) GetProject(ctx context.Context, id primitive.ObjectID) (*models.Project, error) {
+	return projectWithRole(ctx, s.Repo, id, models.RoleViewer)
+}
+
+func (s *ProjectService) UpdateProject(ctx context.Context, id primitive.ObjectID, req models.UpdateProjectRequest) (*models.Project, error) {
+	if !req.HasUpdates() {
+		return nil, utils.BadRequest("no fields to update", nil)
+	}
+
+	err := validation.Validate.Struct(req)
+	if err != nil {
+		errs := utils.FormatValidationErrors(err)
+		return nil, utils.BadRequest("Validation failed", errs)
+	}
+
+	project, err := projectWithRole(ctx, s.Repo, id, models.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		project.Name = *req.Name
+	}
+	if req.Color != nil {
+		project.Color = *req.Color
+	}
+	if req.Archived != nil {
+		if project.Inbox && *req.Archived {
+			return nil, utils.BadRequest("The inbox cannot be archived", nil)
+		}
+		project.Archived = *req.Archived
+	}
+
+	return s.Repo.UpdateProject(ctx, project)
+}
+
+// DeleteProject removes a project. With mode "delete" its tasks go to the
+// trash, from where they can be restored, otherwise they are moved to the
+// owner's inbox.
+func (s *ProjectService) DeleteProject(ctx context.Context, id primitive.ObjectID, mode string) error {
+	project, err := projectWithRole(ctx, s.Repo, id, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if project.Inbox {
+		return utils.BadRequest("The inbox cannot be deleted", nil)
+	}
+
+	switch mode {
+	case "delete":
+		err = s.Tasks.TrashProjectTasks(ctx, project.ID)
+	case "", "move":
+		var inbox *models.Project
+		inbox, err = s.inbox(ctx, project.OwnerID)
+		if err != nil {
+			return err
+		}
+		err = s.Tasks.MoveProjectTasks(ctx, project.ID, inbox.ID)
+	default:
+		return utils.BadRequest("tasks must be one of: move delete", nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.Repo.DeleteProject(ctx, project.ID)
+}
+
+// InviteMember shares a project with another registered user and notifies
+// them by email. Inviting an existing member changes their role instead.
+func (s *ProjectService) InviteMember(ctx context.Context, id primitive.ObjectID, req *models.InviteMemberRequest) (*models.Project, error) {
+	user, _ := s.Users.GetUserByEmail(ctx, req.Email)
+
+	project, err := s.changeMembers(ctx, id, models.RoleOwner, func(project *models.Project) ([]models.ProjectMember, error) {
+		if project.Inbox {
+			return nil, utils.BadRequest("The inbox cannot be shared", nil)
+		}
+		if user == nil {
+			return nil, utils.NotFound("User not found", nil)
+		}
+		if user.ID == project.OwnerID {
+			return nil, utils.BadRequest("User already owns this project", nil)
+		}
+
+		members := slices.DeleteFunc(slices.Clone(project.Members), func(m models.ProjectMember) bool { return m.UserID == user.ID })
+		return append(members, models.ProjectMember{
+			UserID:    user.ID,
+			Email:     user.Email,
+			Role:      req.Role,
+			InvitedAt: time.Now(),
+		}), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inviter, _ := ctx.Value("username").(string)
+	if err := s.SendInvitation(user.Email, inviter, project.Name, req.Role); err != nil {
+		log.Println("Error sending invitation email:", err)
+	}
+
+	return project, nil
+}
+
+func (s *ProjectService) UpdateMember(ctx context.Context, id, userID primitive.ObjectID, role string) (*models.Project, error) {
+	return s.changeMembers(ctx, id, models.RoleOwner, func(project *models.Project) ([]models.ProjectMember, error) {
+		i := slices.IndexFunc(project.Members, func(m models.ProjectMember) bool { return m.UserID == userID })
+		if i < 0 {
+			return nil, utils.NotFound("Member not found", nil)
+		}
+		members := slices.Clone(project.Members)
+		members[i].Role = role
+		return members, nil
+	})
+}
+
+// RemoveMember revokes access. Owners may remove anyone and members may
+// remove themselves to leave a project.
+func (s *ProjectService) RemoveMember(ctx context.Context, id, userID primitive.ObjectID) error {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	need := models.RoleOwner
+	if userID == userObjId {
+		need = models.RoleViewer
+	}
+	_, err = s.changeMembers(ctx, id, need, func(project *models.Project) ([]models.ProjectMember, error) {
+		members := slices.DeleteFunc(slices.Clone(project.Members), func(m models.ProjectMember) bool { return m.UserID == userID })
+		if len(members) == len(project.Members) {
+			return nil, utils.NotFound("Member not found", nil)
+		}
+		return members, nil
+	})
+	return err
+}
+
+// memberRetries is how many times a member change is recomputed when other
+// changes to the same project keep landing first.
+const memberRetries = 5
+
+// changeMembers replaces the members of a project the caller holds at least
+// need on with what change makes of them. The project is read again and
+// change applied again whenever someone else changed the members in
+// between, so concurrent changes are never lost.
+func (s *ProjectService) changeMembers(ctx context.Context, id primitive.ObjectID, need string, change func(*models.Project) ([]models.ProjectMember, error)) (*models.Project, error) {
+	for range memberRetries {
+		project, err := projectWithRole(ctx, s.Repo, id, need)
+		if err != nil {
+			return nil, err
+		}
+		members, err := change(project)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := s.Repo.UpdateMembers(ctx, project.ID, project.Version, members)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			project.Members = members
+			project.Version++
+			return project, nil
+		}
+	}
+	return nil, utils.Conflict("Project members are changing too quickly, try again", nil)
+}
+
+// inbox returns the owner's inbox project, creating it if needed.
+func (s *ProjectService) inbox(ctx context.Context, ownerID primitive.ObjectID) (*models.Project, error) {
+	return s.Repo.EnsureInbox(ctx, ownerID)
+}
+
+// projectWithRole loads a project and checks that the caller holds at least
+// the given role on it.
+func projectWithRole(ctx context.Context, repo repository.ProjectStore, id primitive.ObjectID, need string) (*models.Project, error) {
+	project, err := repo.GetProjectByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !models.RoleAtLeast(project.RoleOf(userObjId), need) {
+		return nil, utils.Unauthorized("Unauthorized access", nil)
+	}
+	return project, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"task-manager/internal/models"
+)
+
+func projectNames(projects []models.Project) []string {
+	out := []string{}
+	for _, p := range projects {
+		out = append(out, p.Name)
+	}
+	return out
+}
+
+func TestInbox(t *testing.T) {
+	e := newEnv(t)
+	ctx, user := e.user("alice")
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			if _, err := e.projects.GetProjects(ctx, false); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	projects, err := e.projects.GetProjects(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || !projects[0].Inbox || projects[0].OwnerID != user.ID {
+		t.Fatalf("projects %+v", projects)
+	}
+	inbox := projects[0]
+
+	archived := true
+	if _, err := e.projects.UpdateProject(ctx, inbox.ID, models.UpdateProjectRequest{Archived: &archived}); code(err) != http.StatusBadRequest {
+		t.Errorf("archiving the inbox: %v", err)
+	}
+	if err := e.projects.DeleteProject(ctx, inbox.ID, ""); code(err) != http.StatusBadRequest {
+		t.Errorf("deleting the inbox: %v", err)
+	}
+}
+
+func TestArchivedProject(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	project, err := e.projects.CreateProject(ctx, &models.CreateProjectRequest{Name: "Old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archived := true
+	if _, err := e.projects.UpdateProject(ctx, project.ID, models.UpdateProjectRequest{Archived: &archived}); err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := e.projects.GetProjects(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := e.projects.GetProjects(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(projectNames(active), []string{"Inbox"}) || !slices.Equal(projectNames(all), []string{"Old", "Inbox"}) {
+		t.Errorf("active %v, all %v", projectNames(active), projectNames(all))
+	}
+
+	req := &models.CreateTaskRequest{Title: "Late", Description: "d", Category: "work", Priority: 1, Status: "pending", ProjectID: project.ID.Hex()}
+	if _, err := e.tasks.CreateTask(ctx, req); code(err) != http.StatusBadRequest {
+		t.Errorf("adding a task to an archived project: %v", err)
+	}
+}
+
+func TestDeleteProjectMovesTasks(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	project, err := e.projects.CreateProject(ctx, &models.CreateProjectRequest{Name: "Launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := e.task(ctx, "Announce", func(r *models.CreateTaskRequest) { r.ProjectID = project.ID.Hex() })
+
+	if err := e.projects.DeleteProject(ctx, project.ID, "sideways"); code(err) != http.StatusBadRequest {
+		t.Errorf("unknown mode: %v", err)
+	}
+	if err := e.projects.DeleteProject(ctx, project.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.projects.GetProject(ctx, project.ID); code(err) != http.StatusNotFound {
+		t.Errorf("deleted project: %v", err)
+	}
+
+	projects, _ := e.projects.GetProjects(ctx, false)
+	moved, err := e.tasks.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.ProjectID == nil || *moved.ProjectID != projects[0].ID || moved.Version != task.Version+1 {
+		t.Errorf("task %+v was not moved to the inbox %s", moved, projects[0].ID.Hex())
+	}
+}
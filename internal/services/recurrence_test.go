@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCompleteRecurringTask(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	due := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	task := e.task(ctx, "Standup", func(r *models.CreateTaskRequest) {
+		r.DueDate = due.Format(time.RFC3339)
+		r.Recurrence = "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3"
+		r.Tags = []string{"team"}
+	})
+
+	completed, pending := "completed", "pending"
+	complete := models.UpdateTaskRequest{Status: &completed}
+	done, err := e.tasks.UpdateTask(ctx, task.ID, complete, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.NextOccurrenceID == nil {
+		t.Fatal("no next occurrence")
+	}
+	next, err := e.tasks.GetTask(ctx, *done.NextOccurrenceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.DueDate.Equal(due.AddDate(0, 0, 3)) || next.Status != "pending" || next.Occurrence != 2 || next.Tags[0] != "team" {
+		t.Errorf("next occurrence %+v", next)
+	}
+
+	// reopening and completing again does not spawn another occurrence
+	if _, err := e.tasks.UpdateTask(ctx, task.ID, models.UpdateTaskRequest{Status: &pending}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.tasks.UpdateTask(ctx, task.ID, complete, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := e.stores.Tasks.CountTasks(ctx, bson.M{"title": "Standup"}); n != 2 {
+		t.Errorf("%d occurrences after completing twice", n)
+	}
+
+	// the third occurrence is the last one the rule allows
+	done, err = e.tasks.UpdateTask(ctx, next.ID, complete, nil)
+	if err != nil || done.NextOccurrenceID == nil {
+		t.Fatalf("completing the second occurrence: %+v, %v", done, err)
+	}
+	done, err = e.tasks.UpdateTask(ctx, *done.NextOccurrenceID, complete, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.NextOccurrenceID != nil {
+		t.Errorf("an occurrence past COUNT was created")
+	}
+}
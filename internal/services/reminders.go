@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MaxReminderWindow is the longest reminder window a user can choose.
+const MaxReminderWindow = 30 * 24 * time.Hour
+
+// ReminderService emails people about tasks that are nearly due. Every
+// reminder is claimed in the NotificationStore before it is sent, so any
+// number of servers can run it side by side and a restart never repeats
+// one.
+type ReminderService struct {
+	Tasks         repository.TaskStore
+	Users         repository.UserStore
+	Notifications repository.NotificationStore
+	// Send delivers a reminder, by email unless replaced
+	Send func(email string, taskTitle string, due time.Time) error
+}
+
+func NewReminderService(tasks repository.TaskStore, users repository.UserStore, notifications repository.NotificationStore) *ReminderService {
+	return &ReminderService{
+		Tasks:         tasks,
+		Users:         users,
+		Notifications: notifications,
+		Send:          utils.SendTaskReminderEmail,
+	}
+}
+
+// SendReminders reminds the owner and assignees of every open task that is
+// due within their reminder window. Each person is reminded once per task
+// and due date, so moving the due date earns a new reminder. It returns how
+// many reminders were sent; one that fails to send is tried again on the
+// next run.
+func (s *ReminderService) SendReminders(ctx context.Context, now time.Time) (int, error) {
+	filter := bson.M{
+		"status":     bson.M{"$ne": "completed"},
+		"deleted_at": nil,
+		"due_date":   bson.M{"$gt": now, "$lte": now.Add(MaxReminderWindow)},
+	}
+	tasks, err := s.Tasks.GetTasks(ctx, filter, bson.D{{Key: "due_date", Value: 1}}, 0, 0)
+	if err != nil {
+		return 0, utils.Internal("Error getting tasks", nil)
+	}
+
+	// users are looked up once per run; nil marks one that is gone
+	users := map[primitive.ObjectID]*models.User{}
+	sent := 0
+	for i := range tasks {
+		// stop between tasks when the server shuts down
+		if ctx.Err() != nil {
+			break
+		}
+		task := &tasks[i]
+		for _, id := range reminderRecipients(task) {
+			user, ok := users[id]
+			if !ok {
+				var err error
+				user, err = s.Users.GetUserByID(ctx, id)
+				if err != nil {
+					log.Println("Error getting reminder recipient:", err)
+				}
+				users[id] = user
+			}
+			if user == nil || !user.Verified {
+				continue
+			}
+			window := user.Settings.ReminderWindow()
+			if window <= 0 || task.DueDate.Sub(now) > window {
+				continue
+			}
+
+			ok, err := s.remind(ctx, user, task)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+// remind sends one reminder unless it has been claimed already. A reminder
+// that cannot be sent is logged and released rather than failing the run.
+func (s *ReminderService) remind(ctx context.Context, user *models.User, task *models.Task) (bool, error) {
+	n := &models.Notification{
+		ID:     fmt.Sprintf("%s:%s:%s:%d", models.NotificationReminder, task.ID.Hex(), user.ID.Hex(), task.DueDate.Unix()),
+		Kind:   models.NotificationReminder,
+		UserID: user.ID,
+		TaskID: &task.ID,
+	}
+	claimed, err := s.Notifications.ClaimNotification(ctx, n)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if err := s.Send(user.Email, task.Title, task.DueDate); err != nil {
+		log.Println("Error sending reminder:", err)
+		return false, s.Notifications.ReleaseNotification(ctx, n.ID)
+	}
+	return true, nil
+}
+
+// RunReminders calls SendReminders every interval until ctx is cancelled.
+func (s *ReminderService) RunReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.SendReminders(ctx, time.Now())
+		if err != nil {
+			log.Println("Error sending reminders:", err)
+		} else if n > 0 {
+			log.Printf("Sent %d task reminders\n", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reminderRecipients are the owner and assignees of task, each once.
+func reminderRecipients(task *models.Task) []primitive.ObjectID {
+	ids := []primitive.ObjectID{task.UserID}
+	for _, id := range task.AssigneeIDs {
+		if id != task.UserID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
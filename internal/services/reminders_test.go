@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSendReminders(t *testing.T) {
+	e := newEnv(t)
+	reminders := NewReminderService(e.stores.Tasks, e.stores.Users, e.stores.Notifications)
+	var sent []string
+	var sendErr error
+	reminders.Send = func(email, taskTitle string, due time.Time) error {
+		if sendErr != nil {
+			return sendErr
+		}
+		sent = append(sent, email+" "+taskTitle)
+		return nil
+	}
+
+	ctx, _ := e.user("alice")
+	_, bob := e.user("bob")
+	_, carol := e.user("carol")
+	off := 0
+	if err := e.stores.Users.UpdateSettings(context.Background(), carol.ID, models.UserSettings{ReminderWindowMinutes: &off}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	due := func(d time.Duration) func(*models.CreateTaskRequest) {
+		return func(r *models.CreateTaskRequest) { r.DueDate = now.Add(d).Format(time.RFC3339) }
+	}
+	soon := e.task(ctx, "Soon", due(time.Hour))
+	e.task(ctx, "Later", due(48*time.Hour))
+	e.task(ctx, "Past", due(-time.Hour))
+	e.task(ctx, "Done", due(time.Hour), func(r *models.CreateTaskRequest) { r.Status = "completed" })
+	if _, err := e.tasks.ReassignTask(ctx, soon.ID, []string{bob.Email, carol.Email}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// an assignee whose account is gone is skipped
+	_, err := e.stores.Tasks.UpdateTask(context.Background(), soon.ID, soon.Version+1, bson.M{"assignee_ids": []primitive.ObjectID{bob.ID, carol.ID, primitive.NewObjectID()}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := reminders.SendReminders(context.Background(), now)
+	if err != nil || n != 2 {
+		t.Fatalf("sent %d, %v", n, err)
+	}
+	if want := []string{"alice@example.com Soon", "bob@example.com Soon"}; !slices.Equal(sent, want) {
+		t.Errorf("sent %v, want %v", sent, want)
+	}
+
+	// nothing is sent twice
+	if n, err := reminders.SendReminders(context.Background(), now); err != nil || n != 0 {
+		t.Errorf("second run sent %d, %v", n, err)
+	}
+
+	// a failed send is tried again on the next run
+	later := e.task(ctx, "Failing", due(2*time.Hour))
+	sendErr = errors.New("mail is down")
+	if n, err := reminders.SendReminders(context.Background(), now); err != nil || n != 0 {
+		t.Errorf("failing run sent %d, %v", n, err)
+	}
+	sendErr, sent = nil, nil
+	if n, err := reminders.SendReminders(context.Background(), now); err != nil || n != 1 || sent[0] != "alice@example.com "+later.Title {
+		t.Errorf("retry sent %d %v, %v", n, sent, err)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+
+	"task-manager/internal/models"
+	"task-manager/internal/search"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// relevanceKey is the sort key for ranking search results by score. Scores
+// are computed per request and never stored, so such lists are sorted and
+// paged here rather than by the store, among at most search.MaxMatches
+// tasks.
+const relevanceKey = "score"
+
+// rankedPage orders matches by relevance under sort, which is relevanceKey
+// descending followed by _id, and cuts out the page that starts after c,
+// or after skip tasks when there is no cursor.
+func rankedPage(matches []models.Task, q *search.Query, sort bson.D, c *taskCursor, limit, skip int) ([]models.Task, bool, error) {
+	idOrder := sort[1].Value.(int)
+	compare := func(a, b *models.Task) int {
+		if a.Score != b.Score {
+			return cmp.Compare(b.Score, a.Score)
+		}
+		return bytes.Compare(a.ID[:], b.ID[:]) * idOrder
+	}
+
+	for i := range matches {
+		matches[i].Score = q.Score(search.TaskFields(&matches[i]))
+	}
+	slices.SortFunc(matches, func(a, b models.Task) int { return compare(&a, &b) })
+
+	if c == nil {
+		if skip >= len(matches) {
+			return []models.Task{}, false, nil
+		}
+		rest := matches[skip:]
+		return rest[:min(limit, len(rest))], len(rest) > limit, nil
+	}
+
+	at := &models.Task{}
+	score, ok := c.Values[0].DoubleOK()
+	if !ok || c.Values[1].Unmarshal(&at.ID) != nil {
+		return nil, false, utils.BadRequest("Invalid cursor", nil)
+	}
+	at.Score = score
+
+	if c.Dir == cursorPrev {
+		// before is where the cursor task is, or would be
+		before := slices.IndexFunc(matches, func(t models.Task) bool { return compare(&t, at) >= 0 })
+		if before < 0 {
+			before = len(matches)
+		}
+		return matches[max(before-limit, 0):before], before > limit, nil
+	}
+
+	// after is where the tasks beyond the cursor begin
+	after := slices.IndexFunc(matches, func(t models.Task) bool { return compare(&t, at) > 0 })
+	if after < 0 {
+		after = len(matches)
+	}
+	rest := matches[after:]
+	return rest[:min(limit, len(rest))], len(rest) > limit, nil
+}
+
+// restrictToMatches narrows filter to the tasks that match q, for search
+// results that are sorted by something other than relevance.
+func (s *TaskService) restrictToMatches(ctx context.Context, filter bson.M, q *search.Query) error {
+	matches, err := s.Repo.SearchTasks(ctx, filter, q)
+	if err != nil {
+		return err
+	}
+	ids := make([]primitive.ObjectID, len(matches))
+	for i, t := range matches {
+		ids[i] = t.ID
+	}
+	filter["_id"] = bson.M{"$in": ids}
+	return nil
+}
+
+// annotateMatches sets the relevance score and highlighted snippets of
+// each task found by q.
+func annotateMatches(tasks []models.Task, q *search.Query) {
+	for i := range tasks {
+		fields := search.TaskFields(&tasks[i])
+		tasks[i].Score = q.Score(fields)
+		tasks[i].Highlights = q.Highlights(fields)
+	}
+}
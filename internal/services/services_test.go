@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+)
+
+// env is the services wired together on the in-memory stores.
+type env struct {
+	t        *testing.T
+	stores   *repository.Stores
+	tasks    *TaskService
+	projects *ProjectService
+	// sent lists the addresses emailed, and sendErr fails every send
+	mu      sync.Mutex
+	sent    []string
+	sendErr error
+}
+
+func newEnv(t *testing.T) *env {
+	stores := repository.NewMemoryStores()
+	tasks := NewTaskService(stores.Tasks, stores.Projects, stores.Users, stores.Comments, stores.History)
+	e := &env{
+		t:        t,
+		stores:   stores,
+		tasks:    tasks,
+		projects: NewProjectService(stores.Projects, tasks, stores.Users),
+	}
+	e.projects.SendInvitation = func(email, inviter, projectName, role string) error {
+		return e.send(email)
+	}
+	e.tasks.SendAssignment = func(email, assigner, taskTitle string) error {
+		return e.send(email)
+	}
+	return e
+}
+
+func (e *env) send(email string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sent = append(e.sent, email)
+	return e.sendErr
+}
+
+// user creates a verified user and returns a context acting as them.
+func (e *env) user(name string) (context.Context, *models.User) {
+	e.t.Helper()
+	user := &models.User{Username: name, Email: name + "@example.com", Verified: true}
+	if err := e.stores.Users.CreateUser(context.Background(), user); err != nil {
+		e.t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), "user_id", user.ID.Hex())
+	ctx = context.WithValue(ctx, "username", name)
+	return ctx, user
+}
+
+// task creates a task as the user of ctx, after edit has had a chance to
+// change the request.
+func (e *env) task(ctx context.Context, title string, edit ...func(*models.CreateTaskRequest)) *models.Task {
+	e.t.Helper()
+	req := &models.CreateTaskRequest{Title: title, Description: "details", Category: "work", Priority: 2, Status: "pending"}
+	for _, f := range edit {
+		f(req)
+	}
+	task, err := e.tasks.CreateTask(ctx, req)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return task
+}
+
+// code is the HTTP status of an AppError, and 0 for nil or any other error.
+func code(err error) int {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return 0
+}
+
+func titles(tasks []models.Task) []string {
+	out := []string{}
+	for _, t := range tasks {
+		out = append(out, t.Title)
+	}
+	return out
+}
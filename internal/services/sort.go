@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SortFields are the task fields a listing may be sorted by.
+var SortFields = []string{"title", "priority", "status", "category", "due_date", "created_at", "updated_at"}
+
+// parseSort turns a sort parameter such as "-priority,due_date,title" into a
+// sort, a leading "-" meaning descending. order=desc, from before sorts had
+// several keys, reverses the whole sort. Tasks without a due date always
+// come after those with one when sorting by due_date, in either direction.
+// The sort always ends with _id so every task has a stable position.
+//
+// Search results are ranked by relevance, best first, unless another sort
+// is given; "score" asks for the ranking explicitly and cannot be combined
+// with other fields.
+func parseSort(param, order string, searching bool) (bson.D, error) {
+	if searching && (param == "" || strings.TrimPrefix(param, "-") == relevanceKey) {
+		return bson.D{{Key: relevanceKey, Value: -1}, {Key: "_id", Value: -1}}, nil
+	}
+
+	sort := bson.D{}
+
+	seen := []string{}
+	if param != "" {
+		for _, part := range strings.Split(param, ",") {
+			part = strings.TrimSpace(part)
+			dir := 1
+			if strings.HasPrefix(part, "-") {
+				dir = -1
+				part = part[1:]
+			}
+			if order == "desc" {
+				dir = -dir
+			}
+
+			switch {
+			case part == relevanceKey && searching:
+				return nil, utils.BadRequest("score cannot be combined with other sort fields", nil)
+			case part == relevanceKey:
+				return nil, utils.BadRequest("score can only sort search results", nil)
+			case !slices.Contains(SortFields, part):
+				return nil, utils.BadRequest(fmt.Sprintf("Unknown sort field %q, expected one of: %s", part, strings.Join(SortFields, ", ")), nil)
+			case slices.Contains(seen, part):
+				return nil, utils.BadRequest(fmt.Sprintf("Sort field %q given more than once", part), nil)
+			}
+			seen = append(seen, part)
+
+			if part == "due_date" {
+				sort = append(sort, bson.E{Key: repository.NoDueDateKey, Value: 1})
+			}
+			sort = append(sort, bson.E{Key: part, Value: dir})
+		}
+	}
+
+	dir := 1
+	if len(sort) > 0 {
+		dir = sort[len(sort)-1].Value.(int)
+	}
+	return append(sort, bson.E{Key: "_id", Value: dir}), nil
+}
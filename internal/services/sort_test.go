@@ -0,0 +1,79 @@
+package services
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		param, order string
+		searching    bool
+		want         bson.D
+		code         int
+	}{
+		{param: "", want: bson.D{{Key: "_id", Value: 1}}},
+		{param: "title", want: bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{param: "-priority, title", want: bson.D{{Key: "priority", Value: -1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{param: "title", order: "desc", want: bson.D{{Key: "title", Value: -1}, {Key: "_id", Value: -1}}},
+		{param: "-due_date", want: bson.D{{Key: repository.NoDueDateKey, Value: 1}, {Key: "due_date", Value: -1}, {Key: "_id", Value: -1}}},
+		{param: "due_date", order: "desc", want: bson.D{{Key: repository.NoDueDateKey, Value: 1}, {Key: "due_date", Value: -1}, {Key: "_id", Value: -1}}},
+		{param: "", searching: true, want: bson.D{{Key: relevanceKey, Value: -1}, {Key: "_id", Value: -1}}},
+		{param: "score", searching: true, want: bson.D{{Key: relevanceKey, Value: -1}, {Key: "_id", Value: -1}}},
+		{param: "title", searching: true, want: bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{param: "title,score", searching: true, code: http.StatusBadRequest},
+		{param: "score", code: http.StatusBadRequest},
+		{param: "description", code: http.StatusBadRequest},
+		{param: "_id", code: http.StatusBadRequest},
+		{param: "title,-title", code: http.StatusBadRequest},
+		{param: "title,", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		got, err := parseSort(tt.param, tt.order, tt.searching)
+		if code(err) != tt.code {
+			t.Errorf("parseSort(%q, %q, %v): %v", tt.param, tt.order, tt.searching, err)
+			continue
+		}
+		if tt.code == 0 && !slices.Equal(got, tt.want) {
+			t.Errorf("parseSort(%q, %q, %v) = %v, want %v", tt.param, tt.order, tt.searching, got, tt.want)
+		}
+	}
+}
+
+func TestSortMissingDueDatesLast(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	due := func(day int) func(*models.CreateTaskRequest) {
+		return func(r *models.CreateTaskRequest) {
+			r.DueDate = time.Date(2026, 11, day, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+		}
+	}
+	e.task(ctx, "None A")
+	e.task(ctx, "Second", due(2))
+	e.task(ctx, "None B")
+	e.task(ctx, "First", due(1))
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"due_date,title", []string{"First", "Second", "None A", "None B"}},
+		{"-due_date,title", []string{"Second", "First", "None A", "None B"}},
+		{"-priority,-title", []string{"Second", "None B", "None A", "First"}},
+	}
+	for _, tt := range tests {
+		page, err := e.tasks.GetTasks(ctx, map[string]string{"sort": tt.sort})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(page.Tasks); !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+}
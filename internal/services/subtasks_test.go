@@ -0,0 +1,90 @@
+package services
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"task-manager/internal/models"
+)
+
+func TestSubtasks(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+
+	project, err := e.projects.CreateProject(ctx, &models.CreateProjectRequest{Name: "Launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := e.task(ctx, "Launch", func(r *models.CreateTaskRequest) { r.ProjectID = project.ID.Hex() })
+	first, err := e.tasks.CreateSubtask(ctx, parent.ID, &models.CreateTaskRequest{Title: "Draft", Description: "d", Category: "work", Priority: 1, Status: "completed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.tasks.CreateSubtask(ctx, parent.ID, &models.CreateTaskRequest{Title: "Review", Description: "d", Category: "work", Priority: 1, Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.tasks.CreateSubtask(ctx, second.ID, &models.CreateTaskRequest{Title: "Proofread", Description: "d", Category: "work", Priority: 1, Status: "pending"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if *first.ParentID != parent.ID || first.ProjectID == nil || *first.ProjectID != project.ID {
+		t.Errorf("subtask %+v does not inherit its parent's project", first)
+	}
+
+	got, err := e.tasks.GetTask(ctx, parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Progress == nil || *got.Progress != (models.TaskProgress{Completed: 1, Total: 2}) {
+		t.Errorf("progress %+v", got.Progress)
+	}
+
+	children, err := e.tasks.GetSubtasks(ctx, parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(titles(children), []string{"Draft", "Review"}) {
+		t.Errorf("subtasks %v", titles(children))
+	}
+
+	page, err := e.tasks.GetTasks(ctx, map[string]string{"view": "tree", "sort": "title"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 1 || !slices.Equal(titles(page.Tasks[0].Subtasks), []string{"Draft", "Review"}) {
+		t.Fatalf("tree %+v", page.Tasks)
+	}
+	if grand := page.Tasks[0].Subtasks[1].Subtasks; !slices.Equal(titles(grand), []string{"Proofread"}) {
+		t.Errorf("grandchildren %v", titles(grand))
+	}
+}
+
+func TestDeleteTaskCascade(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+
+	parent := e.task(ctx, "Parent")
+	child, err := e.tasks.CreateSubtask(ctx, parent.ID, &models.CreateTaskRequest{Title: "Child", Description: "d", Category: "work", Priority: 1, Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.tasks.DeleteTask(ctx, parent.ID, false, nil); code(err) != http.StatusConflict {
+		t.Fatalf("delete without cascade: %v", err)
+	}
+	if err := e.tasks.DeleteTask(ctx, parent.ID, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.tasks.GetTask(ctx, child.ID); code(err) != http.StatusNotFound {
+		t.Errorf("child after cascade: %v", err)
+	}
+	trash, err := e.tasks.GetTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 2 {
+		t.Errorf("trash %v", titles(trash))
+	}
+}
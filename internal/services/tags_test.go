@@ -0,0 +1,74 @@
+package services
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"task-manager/internal/models"
+)
+
+func TestTags(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	infra := e.task(ctx, "Infra", func(r *models.CreateTaskRequest) { r.Tags = []string{" Ops ", "infra", "ops", ""} })
+	both := e.task(ctx, "Both")
+	e.task(ctx, "Untagged")
+
+	if !slices.Equal(infra.Tags, []string{"ops", "infra"}) {
+		t.Errorf("created with tags %v", infra.Tags)
+	}
+
+	tagged, err := e.tasks.AddTags(ctx, both.ID, []string{"INFRA", "ops", "home"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tagged.Tags, []string{"infra", "ops", "home"}) || tagged.Version != 2 {
+		t.Errorf("added tags: %v at version %d", tagged.Tags, tagged.Version)
+	}
+	tagged, err = e.tasks.RemoveTag(ctx, both.ID, "Home", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tagged.Tags, []string{"infra", "ops"}) {
+		t.Errorf("after removing home: %v", tagged.Tags)
+	}
+	if _, err := e.tasks.RemoveTag(ctx, both.ID, "home", nil); code(err) != http.StatusNotFound {
+		t.Errorf("removing a missing tag: %v", err)
+	}
+	stored, _ := e.tasks.GetTask(ctx, both.ID)
+	if !slices.Equal(stored.Tags, tagged.Tags) {
+		t.Errorf("stored tags %v, returned %v", stored.Tags, tagged.Tags)
+	}
+
+	e.task(ctx, "Home", func(r *models.CreateTaskRequest) { r.Tags = []string{"home", "ops"} })
+
+	tests := []struct {
+		filters map[string]string
+		want    []string
+	}{
+		{map[string]string{"tag": "infra"}, []string{"Both", "Infra"}},
+		{map[string]string{"tag": "infra,home"}, []string{"Both", "Home", "Infra"}},
+		{map[string]string{"tag": "Infra, ops", "tag_mode": "all"}, []string{"Both", "Infra"}},
+		{map[string]string{"q": "tag:none"}, []string{"Untagged"}},
+	}
+	for _, tt := range tests {
+		tt.filters["sort"] = "title"
+		page, err := e.tasks.GetTasks(ctx, tt.filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(page.Tasks); !slices.Equal(got, tt.want) {
+			t.Errorf("%v: %v, want %v", tt.filters, got, tt.want)
+		}
+	}
+
+	counts, err := e.tasks.GetTags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.TagCount{{Tag: "ops", Count: 3}, {Tag: "infra", Count: 2}, {Tag: "home", Count: 1}}
+	if !slices.Equal(counts, want) {
+		t.Errorf("counts %v, want %v", counts, want)
+	}
+}
@@ -2,61 +2,134 @@ package services
 
 import (
 	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"task-manager/internal/models"
+	"task-manager/internal/recurrence"
 	"task-manager/internal/repository"
+	"task-manager/internal/search"
+	"task-manager/internal/taskquery"
 	"task-manager/internal/utils"
 	"task-manager/internal/validation"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// task listings return DefaultTaskLimit tasks unless asked for more, and
+// never more than MaxTaskLimit
+const (
+	DefaultTaskLimit = 20
+	MaxTaskLimit     = 100
+)
+
 type TaskService struct {
-	Repo *repository.TaskRepository
+	Repo     repository.TaskStore
+	Projects repository.ProjectStore
+	Users    repository.UserStore
+	Comments repository.CommentStore
+	History  repository.HistoryStore
+	// SendAssignment tells a new assignee about the task, by email unless
+	// replaced
+	SendAssignment func(email string, assigner string, taskTitle string) error
 }
 
-func NewTaskService(repo *repository.TaskRepository) *TaskService {
+func NewTaskService(repo repository.TaskStore, projects repository.ProjectStore, users repository.UserStore, comments repository.CommentStore, history repository.HistoryStore) *TaskService {
 	return &TaskService{
-		Repo: repo,
+		Repo:           repo,
+		Projects:       projects,
+		Users:          users,
+		Comments:       comments,
+		History:        history,
+		SendAssignment: utils.SendTaskAssignmentEmail,
 	}
 }
 
 func (s *TaskService) CreateTask(ctx context.Context, task *models.CreateTaskRequest) (*models.Task, error) {
-	userObjId, err := primitive.ObjectIDFromHex(ctx.Value("user_id").(string))
+	return s.createTask(ctx, task, nil)
+}
+
+// CreateSubtask creates a task nested under an existing task the caller can edit.
+func (s *TaskService) CreateSubtask(ctx context.Context, parentID primitive.ObjectID, task *models.CreateTaskRequest) (*models.Task, error) {
+	parent, err := s.taskWithRole(ctx, parentID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.ProjectID == "" && parent.ProjectID != nil {
+		task.ProjectID = parent.ProjectID.Hex()
+	}
+	return s.createTask(ctx, task, &parent.ID)
+}
+
+func (s *TaskService) createTask(ctx context.Context, task *models.CreateTaskRequest, parentID *primitive.ObjectID) (*models.Task, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectID, err := s.taskProject(ctx, task.ProjectID)
 	if err != nil {
-		return nil, utils.Unauthorized("Unauthorized access", nil)
+		return nil, err
 	}
 
 	var due time.Time
 	if task.DueDate != "" {
 		due, _ = time.Parse(time.RFC3339, task.DueDate)
 	}
+	var completedAt *time.Time
+	if task.Status == "completed" {
+		now := time.Now()
+		completedAt = &now
+	}
 	newTask := &models.Task{
 		UserID:      userObjId,
+		ParentID:    parentID,
+		ProjectID:   projectID,
+		ExternalID:  task.ExternalID,
 		Title:       task.Title,
 		Description: task.Description,
 		Category:    task.Category,
+		Tags:        normalizeTags(task.Tags),
 		Status:      task.Status,
 		Priority:    task.Priority,
 		DueDate:     due,
+		Recurrence:  task.Recurrence,
+		CompletedAt: completedAt,
 	}
 	err = s.Repo.CreateTask(ctx, newTask)
 	if err != nil {
 		return nil, utils.Internal("Error creating task", nil)
 	}
 
+	s.recordHistory(ctx, models.ActionCreated, nil, newTask)
+
 	return newTask, nil
 }
 
-func (s *TaskService) GetTasks(ctx context.Context, filters map[string]string) ([]models.Task, error) {
+// GetTasks lists the tasks visible to the caller. Pages are addressed with
+// opaque cursors; the older page parameter still works but is slower on
+// large collections.
+func (s *TaskService) GetTasks(ctx context.Context, filters map[string]string) (*models.TaskPage, error) {
 
-	userObjId, err := primitive.ObjectIDFromHex(ctx.Value("user_id").(string))
+	userObjId, err := currentUserID(ctx)
 	if err != nil {
-		return nil, utils.Unauthorized("Unauthorized access", nil)
+		return nil, err
+	}
+	filter, err := s.visibleTasksFilter(ctx, userObjId)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := filters["view"] == "tree"
+	if tree {
+		filter["parent_id"] = nil
 	}
-	filter := bson.M{"user_id": userObjId}
 
 	if v, ok := filters["category"]; ok && v != "" {
 		filter["category"] = v
@@ -64,47 +137,353 @@ func (s *TaskService) GetTasks(ctx context.Context, filters map[string]string) (
 	if v, ok := filters["status"]; ok && v != "" {
 		filter["status"] = v
 	}
+	if v, ok := filters["project"]; ok && v != "" {
+		projectId, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return nil, utils.BadRequest("Invalid project id", nil)
+		}
+		filter["project_id"] = projectId
+	}
 
-	if v, ok := filters["search"]; ok && v != "" {
-		filter["$or"] = []bson.M{
-			{"title": bson.M{"$regex": v, "$options": "i"}},
-			{"description": bson.M{"$regex": v, "$options": "i"}},
+	if v, ok := filters["assigned"]; ok && v != "" {
+		if v != "me" {
+			return nil, utils.BadRequest("assigned must be: me", nil)
+		}
+		filter["assignee_ids"] = userObjId
+	}
+
+	if v, ok := filters["tag"]; ok && v != "" {
+		tags := normalizeTags(strings.Split(v, ","))
+		if filters["tag_mode"] == "all" {
+			filter["tags"] = bson.M{"$all": tags}
+		} else {
+			filter["tags"] = bson.M{"$in": tags}
 		}
 	}
 
-	sort := bson.D{}
-	if v, ok := filters["sort"]; ok && v != "" {
-		order := 1
-		if v2, ok2 := filters["order"]; ok2 && v2 == "desc" {
-			order = -1
+	if v, ok := filters["blocked"]; ok && v != "" {
+		blocked, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, utils.BadRequest("blocked must be true or false", nil)
 		}
-		sort = append(sort, bson.E{Key: v, Value: order})
+		visible, err := s.visibleTasksFilter(ctx, userObjId)
+		if err != nil {
+			return nil, err
+		}
+		open, err := s.openTaskIDs(ctx, visible)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			filter["blocked_by"] = bson.M{"$in": open}
+		} else {
+			filter["blocked_by"] = bson.M{"$nin": open}
+		}
+	}
+
+	if v, ok := filters["q"]; ok && v != "" {
+		user, err := s.Users.GetUserByID(ctx, userObjId)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := compileQuery(v, time.Now().In(user.Settings.Location()))
+		if err != nil {
+			return nil, err
+		}
+		filter["$and"] = append(filter["$and"].([]bson.M), expr)
+	}
+
+	var query *search.Query
+	if v, ok := filters["search"]; ok && v != "" {
+		query, err = search.Parse(v)
+		if err != nil {
+			return nil, utils.BadRequest("Invalid search: "+err.Error(), nil)
+		}
+	}
+	sort, err := parseSort(filters["sort"], filters["order"], query != nil)
+	if err != nil {
+		return nil, err
 	}
+	ranked := hasSortKey(sort, relevanceKey)
 
-	limit := 20
+	limit := DefaultTaskLimit
 	if v, ok := filters["limit"]; ok && v != "" {
 		limit, _ = strconv.Atoi(v)
+		if limit <= 0 {
+			limit = DefaultTaskLimit
+		}
 	}
+	limit = min(limit, MaxTaskLimit)
 
+	var c *taskCursor
 	skip := 0
-	if v, ok := filters["page"]; ok && v != "" {
-		page, _ := strconv.Atoi(v)
-		if page > 1 {
-			skip = (page - 1) * limit
+	if v, ok := filters["cursor"]; ok && v != "" {
+		c, err = decodeCursor(v, sort)
+		if err != nil {
+			return nil, err
+		}
+	} else if v, ok := filters["page"]; ok && v != "" {
+		p, _ := strconv.Atoi(v)
+		if p > 1 {
+			skip = (p - 1) * limit
 		}
 	}
 
-	tasks, err := s.Repo.GetTasks(ctx, filter, sort, limit, skip)
+	page := &models.TaskPage{}
+	var tasks []models.Task
+	var hasMore bool
+	if ranked {
+		var matches []models.Task
+		matches, err = s.Repo.SearchTasks(ctx, filter, query)
+		if err != nil {
+			return nil, err
+		}
+		if filters["total"] == "true" {
+			total := int64(len(matches))
+			page.Total = &total
+		}
+		tasks, hasMore, err = rankedPage(matches, query, sort, c, limit, skip)
+	} else {
+		if query != nil {
+			err = s.restrictToMatches(ctx, filter, query)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if filters["total"] == "true" {
+			total, err := s.Repo.CountTasks(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			page.Total = &total
+		}
+		tasks, hasMore, err = s.sortedPage(ctx, filter, sort, c, limit, skip)
+	}
 	if err != nil {
-		return nil, utils.Internal("Error getting tasks", nil)
+		return nil, err
 	}
 
-	return tasks, nil
+	dir := cursorNext
+	if c != nil {
+		dir = c.Dir
+	}
+	page.NextCursor, page.PrevCursor, err = pageCursors(tasks, sort, dir, hasMore, c != nil || skip > 0)
+	if err != nil {
+		return nil, utils.Internal("Error building cursors", nil)
+	}
+
+	if query != nil {
+		annotateMatches(tasks, query)
+	}
+
+	if tree {
+		err = s.attachSubtasks(ctx, tasks, sort)
+	} else {
+		err = s.rollupProgress(ctx, tasks)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.countComments(ctx, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	page.Tasks = tasks
+	page.Count = len(tasks)
+	return page, nil
+}
+
+// compileQuery parses a filter expression such as "status:pending
+// priority>=3" into a task filter. Relative dates like "today" are days in
+// now's location, which is the caller's time zone.
+func compileQuery(q string, now time.Time) (bson.M, error) {
+	node, err := taskquery.Parse(q)
+	if err == nil {
+		var expr bson.M
+		expr, err = taskquery.Compile(node, now)
+		if err == nil {
+			return expr, nil
+		}
+	}
+
+	var qerr *taskquery.Error
+	if errors.As(err, &qerr) {
+		return nil, utils.BadRequest("Invalid filter: "+qerr.Error(), map[string]int{"position": qerr.Pos})
+	}
+	return nil, utils.Internal("Error compiling filter", nil)
+}
+
+// sortedPage fetches the page of tasks matching filter that starts after c,
+// or after skip tasks when there is no cursor, in sort order. hasMore
+// reports whether more tasks lie beyond the page in the direction walked.
+func (s *TaskService) sortedPage(ctx context.Context, filter bson.M, sort bson.D, c *taskCursor, limit, skip int) ([]models.Task, bool, error) {
+	querySort := sort
+	if c != nil {
+		if c.Dir == cursorPrev {
+			querySort = reverseSort(sort)
+		}
+		filter["$and"] = append(filter["$and"].([]bson.M), afterFilter(querySort, c.Values))
+	}
+
+	// one extra task tells whether there is another page
+	tasks, err := s.Repo.GetTasks(ctx, filter, querySort, limit+1, skip)
+	if err != nil {
+		return nil, false, utils.Internal("Error getting tasks", nil)
+	}
+	hasMore := len(tasks) > limit
+	if hasMore {
+		tasks = tasks[:limit]
+	}
+	if c != nil && c.Dir == cursorPrev {
+		slices.Reverse(tasks)
+	}
+	return tasks, hasMore, nil
+}
+
+// countComments sets CommentCount on each task.
+func (s *TaskService) countComments(ctx context.Context, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	counts, err := s.Comments.CommentCounts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		tasks[i].CommentCount = counts[tasks[i].ID]
+	}
+	return nil
+}
+
+// GetTask returns one task the caller can see, with its progress and comment
+// count filled in.
+func (s *TaskService) GetTask(ctx context.Context, id primitive.ObjectID) (*models.Task, error) {
+	task, err := s.taskWithRole(ctx, id, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{*task}
+	err = s.rollupProgress(ctx, tasks)
+	if err != nil {
+		return nil, err
+	}
+	err = s.countComments(ctx, tasks)
+	if err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// GetSubtasks lists the direct children of a task visible to the caller.
+func (s *TaskService) GetSubtasks(ctx context.Context, parentID primitive.ObjectID) ([]models.Task, error) {
+	parent, err := s.taskWithRole(ctx, parentID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := s.Repo.GetTasks(ctx, bson.M{"parent_id": parent.ID, "deleted_at": nil}, bson.D{{Key: "created_at", Value: 1}}, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting subtasks", nil)
+	}
+
+	err = s.rollupProgress(ctx, children)
+	if err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// children loads the direct children of every given task, grouped by parent.
+func (s *TaskService) children(ctx context.Context, tasks []models.Task, sort bson.D) (map[primitive.ObjectID][]models.Task, error) {
+	grouped := map[primitive.ObjectID][]models.Task{}
+	if len(tasks) == 0 {
+		return grouped, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	children, err := s.Repo.GetTasks(ctx, bson.M{"parent_id": bson.M{"$in": ids}, "deleted_at": nil}, sort, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting subtasks", nil)
+	}
+
+	for _, c := range children {
+		grouped[*c.ParentID] = append(grouped[*c.ParentID], c)
+	}
+	return grouped, nil
+}
+
+// rollupProgress sets Progress on every task that has children.
+func (s *TaskService) rollupProgress(ctx context.Context, tasks []models.Task) error {
+	grouped, err := s.children(ctx, tasks, nil)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		tasks[i].Progress = progressOf(grouped[tasks[i].ID])
+	}
+	return nil
+}
+
+// attachSubtasks fills Subtasks and Progress recursively, one level per query.
+func (s *TaskService) attachSubtasks(ctx context.Context, tasks []models.Task, sort bson.D) error {
+	grouped, err := s.children(ctx, tasks, sort)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		kids := grouped[tasks[i].ID]
+		if len(kids) == 0 {
+			continue
+		}
+		if err := s.attachSubtasks(ctx, kids, sort); err != nil {
+			return err
+		}
+		tasks[i].Subtasks = kids
+		tasks[i].Progress = progressOf(kids)
+	}
+	return nil
+}
+
+func progressOf(children []models.Task) *models.TaskProgress {
+	if len(children) == 0 {
+		return nil
+	}
+	p := &models.TaskProgress{Total: len(children)}
+	for _, c := range children {
+		if c.Status == "completed" {
+			p.Completed++
+		}
+	}
+	return p
 }
 
-func (s *TaskService) UpdateTask(ctx context.Context, id primitive.ObjectID, req models.UpdateTaskRequest) (*models.Task, error) {
+// UpdateTask applies req to a task. ifMatch lists the versions the caller
+// expects the task to be at; nil means any version.
+func (s *TaskService) UpdateTask(ctx context.Context, id primitive.ObjectID, req models.UpdateTaskRequest, ifMatch []int) (*models.Task, error) {
+	return s.PatchTask(ctx, id, models.TaskPatch{UpdateTaskRequest: req}, ifMatch)
+}
 
-	if !req.HasUpdates() {
+// PatchTask sets the fields given in patch and clears the ones listed in
+// patch.Clear, touching nothing else on the stored task. The write only
+// succeeds if the task has not changed since it was read.
+func (s *TaskService) PatchTask(ctx context.Context, id primitive.ObjectID, patch models.TaskPatch, ifMatch []int) (*models.Task, error) {
+	req := patch.UpdateTaskRequest
+	if !req.HasUpdates() && len(patch.Clear) == 0 {
 		return nil, utils.BadRequest("no fields to update", nil)
 	}
 
@@ -113,64 +492,952 @@ func (s *TaskService) UpdateTask(ctx context.Context, id primitive.ObjectID, req
 		errs := utils.FormatValidationErrors(err)
 		return nil, utils.BadRequest("Validation failed", errs)
 	}
+	for _, field := range patch.Clear {
+		if !slices.Contains(models.ClearableTaskFields, field) {
+			return nil, utils.BadRequest(field+" cannot be cleared", nil)
+		}
+	}
 
-	task, err := s.Repo.GetTaskByID(ctx, id)
+	task, err := s.taskWithRole(ctx, id, models.RoleEditor)
 	if err != nil {
 		return nil, err
 	}
-
-	userObjId, err := primitive.ObjectIDFromHex(ctx.Value("user_id").(string))
+	err = checkVersion(task, ifMatch)
 	if err != nil {
-		return nil, utils.Unauthorized("Unauthorized access", nil)
-	}
-	if task.UserID != userObjId {
-		return nil, utils.Unauthorized("Unauthorized access", nil)
+		return nil, err
 	}
+	before := cloneTask(task)
+
+	set := bson.M{}
+	unset := slices.Clone(patch.Clear)
 
 	if req.Title != nil {
-		task.Title = *req.Title
+		set["title"] = *req.Title
 	}
 	if req.Description != nil {
-		task.Description = *req.Description
+		set["description"] = *req.Description
+	}
+	if req.Category != nil {
+		set["category"] = *req.Category
+	}
+	if req.Tags != nil {
+		set["tags"] = normalizeTags(*req.Tags)
+	}
+	completing := req.Status != nil && *req.Status == "completed" && task.Status != "completed"
+	if completing {
+		blockers, err := s.openBlockers(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockers) > 0 {
+			return nil, utils.Conflict("Task is blocked by unfinished tasks", blockers)
+		}
 	}
 	if req.Status != nil {
-		task.Status = *req.Status
+		set["status"] = *req.Status
+		if completing {
+			set["completed_at"] = time.Now()
+		} else if *req.Status != "completed" && task.Status == "completed" {
+			unset = append(unset, "completed_at")
+		}
 	}
 	if req.Priority != nil {
-		task.Priority = *req.Priority
+		set["priority"] = *req.Priority
 	}
-
-	var due time.Time
 	if req.DueDate != nil {
-		due, _ = time.Parse(time.RFC3339, *req.DueDate)
-		task.DueDate = due
+		due, _ := time.Parse(time.RFC3339, *req.DueDate)
+		set["due_date"] = due
+	}
+	if req.Recurrence != nil {
+		set["recurrence"] = *req.Recurrence
+	}
+	if req.ProjectID != nil {
+		projectID, err := s.taskProject(ctx, *req.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if projectID != nil {
+			set["project_id"] = *projectID
+		} else {
+			unset = append(unset, "project_id")
+		}
 	}
 
-	updatedTask, err := s.Repo.UpdateTask(ctx, task)
+	updatedTask, err := s.Repo.UpdateTask(ctx, task.ID, task.Version, set, unset)
 	if err != nil {
 		return nil, err
 	}
 
+	if completing && updatedTask.Recurrence != "" && updatedTask.NextOccurrenceID == nil {
+		next, err := s.spawnNextOccurrence(ctx, updatedTask)
+		if err != nil {
+			return nil, err
+		}
+		if next != nil {
+			updatedTask, err = s.Repo.UpdateTask(ctx, updatedTask.ID, updatedTask.Version, bson.M{"next_occurrence_id": next.ID}, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s.recordHistory(ctx, models.ActionUpdated, before, updatedTask)
+
 	return updatedTask, nil
 }
 
-func (s *TaskService) DeleteTask(ctx context.Context, id primitive.ObjectID) error {
+func (s *TaskService) AddTags(ctx context.Context, id primitive.ObjectID, tags []string, ifMatch []int) (*models.Task, error) {
+	task, err := s.taskWithRole(ctx, id, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	err = checkVersion(task, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	before := cloneTask(task)
 
-	task, err := s.Repo.GetTaskByID(ctx, id)
+	tags = normalizeTags(tags)
+	err = s.Repo.AddTags(ctx, task.ID, task.Version, tags)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	userObjId, err := primitive.ObjectIDFromHex(ctx.Value("user_id").(string))
+	task.Version++
+
+	for _, tag := range tags {
+		if !slices.Contains(task.Tags, tag) {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+	s.recordHistory(ctx, models.ActionUpdated, before, task)
+	return task, nil
+}
+
+func (s *TaskService) RemoveTag(ctx context.Context, id primitive.ObjectID, tag string, ifMatch []int) (*models.Task, error) {
+	task, err := s.taskWithRole(ctx, id, models.RoleEditor)
 	if err != nil {
-		return utils.Unauthorized("Unauthorized access", nil)
+		return nil, err
+	}
+	err = checkVersion(task, ifMatch)
+	if err != nil {
+		return nil, err
 	}
-	if task.UserID != userObjId {
-		return utils.Unauthorized("Unauthorized access", nil)
+
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !slices.Contains(task.Tags, tag) {
+		return nil, utils.NotFound("Tag not found on task", nil)
 	}
 
-	err = s.Repo.DeleteTask(ctx, id)
+	before := cloneTask(task)
+
+	err = s.Repo.RemoveTag(ctx, task.ID, task.Version, tag)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	task.Version++
+
+	task.Tags = slices.DeleteFunc(task.Tags, func(t string) bool { return t == tag })
+	s.recordHistory(ctx, models.ActionUpdated, before, task)
+	return task, nil
+}
+
+// GetTags lists the caller's tags with the number of tasks using each.
+func (s *TaskService) GetTags(ctx context.Context) ([]models.TagCount, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Repo.TagCounts(ctx, userObjId)
+}
+
+// normalizeTags lowercases and trims tags, dropping blanks and duplicates.
+func normalizeTags(tags []string) []string {
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// spawnNextOccurrence creates the task following a completed occurrence of a
+// recurring task, with the due date rolled forward by the rule, and returns
+// it. Nothing is created once the rule is exhausted.
+func (s *TaskService) spawnNextOccurrence(ctx context.Context, task *models.Task) (*models.Task, error) {
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return nil, utils.Internal("Invalid recurrence rule on task", nil)
+	}
+
+	prev := task.DueDate
+	if prev.IsZero() {
+		prev = time.Now().UTC()
+	}
+	occurrence := max(task.Occurrence, 1)
+
+	next, ok := rule.Next(prev, occurrence)
+	if !ok {
+		return nil, nil
+	}
+
+	nextTask := &models.Task{
+		UserID:      task.UserID,
+		ParentID:    task.ParentID,
+		Title:       task.Title,
+		Description: task.Description,
+		ProjectID:   task.ProjectID,
+		AssigneeIDs: task.AssigneeIDs,
+		Category:    task.Category,
+		Tags:        task.Tags,
+		Status:      "pending",
+		Priority:    task.Priority,
+		DueDate:     next,
+		Recurrence:  task.Recurrence,
+		Occurrence:  occurrence + 1,
+	}
+	err = s.Repo.CreateTask(ctx, nextTask)
+	if err != nil {
+		return nil, utils.Internal("Error creating next occurrence", nil)
+	}
+	s.recordHistory(ctx, models.ActionCreated, nil, nextTask)
+	return nextTask, nil
+}
+
+// AssignTask adds a registered user as an assignee and emails them. Assigning
+// someone who is already assigned is a no-op.
+func (s *TaskService) AssignTask(ctx context.Context, id primitive.ObjectID, email string, ifMatch []int) (*models.Task, error) {
+	user, _ := s.Users.GetUserByEmail(ctx, email)
+
+	added := false
+	task, err := s.changeAssignees(ctx, id, models.RoleEditor, ifMatch, func(task *models.Task) ([]primitive.ObjectID, error) {
+		if user == nil {
+			return nil, utils.NotFound("User not found", nil)
+		}
+		added = !slices.Contains(task.AssigneeIDs, user.ID)
+		if !added {
+			return nil, nil
+		}
+		return append(slices.Clone(task.AssigneeIDs), user.ID), nil
+	})
+	if err != nil || !added {
+		return task, err
+	}
+
+	// the assignment stands even if the email cannot be sent
+	assigner, _ := ctx.Value("username").(string)
+	if err := s.SendAssignment(user.Email, assigner, task.Title); err != nil {
+		log.Println("Error sending assignment email:", err)
+	}
+	return task, nil
+}
+
+func (s *TaskService) UnassignTask(ctx context.Context, id, userID primitive.ObjectID, ifMatch []int) (*models.Task, error) {
+	return s.changeAssignees(ctx, id, models.RoleEditor, ifMatch, func(task *models.Task) ([]primitive.ObjectID, error) {
+		if !slices.Contains(task.AssigneeIDs, userID) {
+			return nil, utils.NotFound("Assignee not found", nil)
+		}
+		return slices.DeleteFunc(slices.Clone(task.AssigneeIDs), func(a primitive.ObjectID) bool { return a == userID }), nil
+	})
+}
+
+// ReassignTask replaces the assignees of a task. Only its owner may do this;
+// newly added assignees are emailed.
+func (s *TaskService) ReassignTask(ctx context.Context, id primitive.ObjectID, emails []string, ifMatch []int) (*models.Task, error) {
+	users := []*models.User{}
+	for _, email := range emails {
+		user, _ := s.Users.GetUserByEmail(ctx, email)
+		if user == nil {
+			return nil, utils.NotFound("User not found", map[string]string{"email": email})
+		}
+		users = append(users, user)
+	}
+
+	var added []string
+	task, err := s.changeAssignees(ctx, id, models.RoleOwner, ifMatch, func(task *models.Task) ([]primitive.ObjectID, error) {
+		assignees := []primitive.ObjectID{}
+		added = nil
+		for _, user := range users {
+			if slices.Contains(assignees, user.ID) {
+				continue
+			}
+			assignees = append(assignees, user.ID)
+			if !slices.Contains(task.AssigneeIDs, user.ID) {
+				added = append(added, user.Email)
+			}
+		}
+		return assignees, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the assignment stands even if an email cannot be sent
+	assigner, _ := ctx.Value("username").(string)
+	for _, email := range added {
+		if err := s.SendAssignment(email, assigner, task.Title); err != nil {
+			log.Println("Error sending assignment email:", err)
+		}
+	}
+	return task, nil
+}
+
+// assigneeRetries is how many times an assignee change is recomputed when
+// other changes to the same task keep landing first.
+const assigneeRetries = 5
+
+// changeAssignees replaces the assignees of a task the caller holds at least
+// need on with what change makes of them, or leaves the task as it is when
+// change returns nil. Like ProjectService.changeMembers it reads the task
+// again and reapplies change whenever the task was modified in between,
+// unless ifMatch pins the version the change was meant for.
+func (s *TaskService) changeAssignees(ctx context.Context, id primitive.ObjectID, need string, ifMatch []int, change func(*models.Task) ([]primitive.ObjectID, error)) (*models.Task, error) {
+	for range assigneeRetries {
+		task, err := s.taskWithRole(ctx, id, need)
+		if err != nil {
+			return nil, err
+		}
+		err = checkVersion(task, ifMatch)
+		if err != nil {
+			return nil, err
+		}
+		assignees, err := change(task)
+		if err != nil {
+			return nil, err
+		}
+		if assignees == nil {
+			return task, nil
+		}
+
+		updated, err := s.Repo.SetAssignees(ctx, task.ID, task.Version, assignees)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			before := cloneTask(task)
+			task.AssigneeIDs = assignees
+			task.Version++
+			s.recordHistory(ctx, models.ActionUpdated, before, task)
+			return task, nil
+		}
+	}
+	return nil, utils.Conflict("Task assignees are changing too quickly, try again", nil)
+}
+
+// BulkTasks applies one action to every task in req.IDs through the same
+// service methods, and so the same permission checks, as the single-task
+// endpoints. A failure on one task does not stop the others.
+func (s *TaskService) BulkTasks(ctx context.Context, req *models.BulkTaskRequest) ([]models.BulkResult, error) {
+	var apply func(id primitive.ObjectID) error
+
+	switch req.Action {
+	case models.BulkUpdate:
+		if req.Update == nil || !req.Update.HasUpdates() {
+			return nil, utils.BadRequest("update is required for the update action", nil)
+		}
+		apply = func(id primitive.ObjectID) error {
+			_, err := s.UpdateTask(ctx, id, *req.Update, nil)
+			return err
+		}
+	case models.BulkStatus:
+		if req.Status == "" {
+			return nil, utils.BadRequest("status is required for the status action", nil)
+		}
+		apply = func(id primitive.ObjectID) error {
+			_, err := s.UpdateTask(ctx, id, models.UpdateTaskRequest{Status: &req.Status}, nil)
+			return err
+		}
+	case models.BulkAddTag:
+		if strings.TrimSpace(req.Tag) == "" {
+			return nil, utils.BadRequest("tag is required for the add_tag action", nil)
+		}
+		apply = func(id primitive.ObjectID) error {
+			_, err := s.AddTags(ctx, id, []string{req.Tag}, nil)
+			return err
+		}
+	case models.BulkDelete:
+		apply = func(id primitive.ObjectID) error {
+			return s.DeleteTask(ctx, id, req.Cascade, nil)
+		}
+	case models.BulkMoveProject:
+		if req.ProjectID == nil {
+			return nil, utils.BadRequest("project_id is required for the move_project action", nil)
+		}
+		apply = func(id primitive.ObjectID) error {
+			_, err := s.UpdateTask(ctx, id, models.UpdateTaskRequest{ProjectID: req.ProjectID}, nil)
+			return err
+		}
+	default:
+		return nil, utils.BadRequest("action must be one of: update status add_tag delete move_project", nil)
+	}
+
+	results := []models.BulkResult{}
+	seen := map[string]bool{}
+	for _, hex := range req.IDs {
+		if seen[hex] {
+			continue
+		}
+		seen[hex] = true
+
+		result := models.BulkResult{ID: hex, Success: true}
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			err = utils.BadRequest("Invalid task id", nil)
+		} else {
+			err = apply(id)
+		}
+
+		if err != nil {
+			result.Success = false
+			if appErr, ok := err.(*utils.AppError); ok {
+				result.Code = appErr.Code
+				result.Error = appErr.Message
+				result.Errors = appErr.Errors
+			} else {
+				result.Code = http.StatusInternalServerError
+				result.Error = "Internal Server error"
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// AddDependency marks id as blocked by blockerID. Both tasks must belong to
+// the caller and the new edge must not close a cycle.
+func (s *TaskService) AddDependency(ctx context.Context, id, blockerID primitive.ObjectID, ifMatch []int) (*models.Task, error) {
+	if id == blockerID {
+		return nil, utils.BadRequest("A task cannot block itself", nil)
+	}
+
+	task, err := s.taskWithRole(ctx, id, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	err = checkVersion(task, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+	blocker, err := s.taskWithRole(ctx, blockerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.UserID != blocker.UserID {
+		return nil, utils.BadRequest("Dependencies can only link tasks with the same owner", nil)
+	}
+
+	if slices.Contains(task.BlockedBy, blocker.ID) {
+		return task, nil
+	}
+
+	cycle, err := s.dependsOn(ctx, blocker, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, utils.Conflict("Dependency would create a cycle", nil)
+	}
+
+	before := cloneTask(task)
+
+	err = s.Repo.AddBlocker(ctx, task.ID, task.Version, blocker.ID)
+	if err != nil {
+		return nil, err
+	}
+	task.Version++
+
+	task.BlockedBy = append(task.BlockedBy, blocker.ID)
+	s.recordHistory(ctx, models.ActionUpdated, before, task)
+	return task, nil
+}
+
+func (s *TaskService) RemoveDependency(ctx context.Context, id, blockerID primitive.ObjectID, ifMatch []int) (*models.Task, error) {
+	task, err := s.taskWithRole(ctx, id, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	err = checkVersion(task, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(task.BlockedBy, blockerID) {
+		return nil, utils.NotFound("Dependency not found", nil)
+	}
+
+	before := cloneTask(task)
+
+	err = s.Repo.RemoveBlocker(ctx, task.ID, task.Version, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	task.Version++
+
+	task.BlockedBy = slices.DeleteFunc(task.BlockedBy, func(b primitive.ObjectID) bool { return b == blockerID })
+	s.recordHistory(ctx, models.ActionUpdated, before, task)
+	return task, nil
+}
+
+// dependsOn reports whether target is reachable from task by following
+// blocked_by edges across the owner's tasks.
+func (s *TaskService) dependsOn(ctx context.Context, task *models.Task, target primitive.ObjectID) (bool, error) {
+	all, err := s.Repo.GetTasks(ctx, bson.M{"user_id": task.UserID}, nil, 0, 0)
+	if err != nil {
+		return false, utils.Internal("Error getting tasks", nil)
+	}
+
+	edges := map[primitive.ObjectID][]primitive.ObjectID{}
+	for _, t := range all {
+		edges[t.ID] = t.BlockedBy
+	}
+
+	seen := map[primitive.ObjectID]bool{}
+	stack := []primitive.ObjectID{task.ID}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == target {
+			return true, nil
+		}
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+		stack = append(stack, edges[current]...)
+	}
+	return false, nil
+}
+
+// openBlockers returns the ids of blockers of task that are not completed.
+// Blockers that no longer exist or are in the trash do not block.
+func (s *TaskService) openBlockers(ctx context.Context, task *models.Task) ([]primitive.ObjectID, error) {
+	if len(task.BlockedBy) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": task.BlockedBy}, "status": bson.M{"$ne": "completed"}, "deleted_at": nil}
+	blockers, err := s.Repo.GetTasks(ctx, filter, nil, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error checking dependencies", nil)
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, b := range blockers {
+		ids = append(ids, b.ID)
+	}
+	return ids, nil
+}
+
+// openTaskIDs returns the ids of every unfinished task matching visible.
+func (s *TaskService) openTaskIDs(ctx context.Context, visible bson.M) ([]primitive.ObjectID, error) {
+	visible["status"] = bson.M{"$ne": "completed"}
+	open, err := s.Repo.GetTasks(ctx, visible, nil, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting tasks", nil)
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, t := range open {
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+// DeleteTask moves a task to the trash. Tasks with subtasks are only trashed
+// when cascade is set, in which case every descendant is trashed with them.
+func (s *TaskService) DeleteTask(ctx context.Context, id primitive.ObjectID, cascade bool, ifMatch []int) error {
+
+	task, err := s.taskWithRole(ctx, id, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	err = checkVersion(task, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	descendants, err := s.descendants(ctx, task.ID, nil)
+	if err != nil {
+		return err
+	}
+	if len(descendants) > 0 && !cascade {
+		return utils.Conflict("Task has subtasks, pass cascade=true to delete them too", nil)
+	}
+
+	return s.trashTree(ctx, task, descendants, time.Now())
+}
+
+// MoveProjectTasks moves every task of a project, including those in the
+// trash, to another project and records the move in each task's history.
+// The caller must have checked that the project may be deleted.
+func (s *TaskService) MoveProjectTasks(ctx context.Context, from, to primitive.ObjectID) error {
+	tasks, err := s.Repo.GetTasks(ctx, bson.M{"project_id": from}, nil, 0, 0)
+	if err != nil {
+		return utils.Internal("Error getting tasks", nil)
+	}
+
+	for i := range tasks {
+		err = s.moveTask(ctx, &tasks[i], from, to)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveRetries is how many times a task is read again when it keeps changing
+// while it is being moved.
+const moveRetries = 5
+
+// moveTask moves task from one project to another, reading it again whenever
+// it was modified since task was read. A task that has meanwhile left the
+// project is not moved.
+func (s *TaskService) moveTask(ctx context.Context, task *models.Task, from, to primitive.ObjectID) error {
+	for range moveRetries {
+		if task.ProjectID == nil || *task.ProjectID != from {
+			return nil
+		}
+
+		updated, err := s.Repo.UpdateTask(ctx, task.ID, task.Version, bson.M{"project_id": to}, nil)
+		if err == nil {
+			s.recordHistory(ctx, models.ActionUpdated, task, updated)
+			return nil
+		}
+		if appErr, ok := err.(*utils.AppError); !ok || appErr.Code != http.StatusPreconditionFailed {
+			return err
+		}
+
+		task, err = s.Repo.GetTaskByID(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return utils.Conflict("Tasks are changing too quickly to move them, try again", nil)
+}
+
+// TrashProjectTasks moves every task of a project, with their subtasks, to
+// the trash, as DeleteTask with cascade would one by one. The caller must
+// have checked that the project may be deleted.
+func (s *TaskService) TrashProjectTasks(ctx context.Context, projectID primitive.ObjectID) error {
+	tasks, err := s.Repo.GetTasks(ctx, bson.M{"project_id": projectID, "deleted_at": nil}, nil, 0, 0)
+	if err != nil {
+		return utils.Internal("Error getting tasks", nil)
+	}
+	inProject := map[primitive.ObjectID]bool{}
+	for _, task := range tasks {
+		inProject[task.ID] = true
+	}
+
+	deletedAt := time.Now()
+	for i := range tasks {
+		task := &tasks[i]
+		// subtasks are trashed along with their parent
+		if task.ParentID != nil && inProject[*task.ParentID] {
+			continue
+		}
+		descendants, err := s.descendants(ctx, task.ID, nil)
+		if err != nil {
+			return err
+		}
+		err = s.trashTree(ctx, task, descendants, deletedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashTree trashes task and its descendants, children first, with one
+// timestamp for the whole subtree so it can be restored together.
+func (s *TaskService) trashTree(ctx context.Context, task *models.Task, descendants []primitive.ObjectID, deletedAt time.Time) error {
+	for i := len(descendants) - 1; i >= 0; i-- {
+		child, err := s.Repo.GetTaskByID(ctx, descendants[i])
+		if err != nil {
+			return err
+		}
+		err = s.trashTask(ctx, child, deletedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.trashTask(ctx, task, deletedAt)
+}
+
+// trashTask moves a single task to the trash, unless it changed since it was
+// read, and records the change.
+func (s *TaskService) trashTask(ctx context.Context, task *models.Task, deletedAt time.Time) error {
+	before := cloneTask(task)
+
+	err := s.Repo.DeleteTask(ctx, task.ID, task.Version, deletedAt)
+	if err != nil {
+		return err
+	}
+	task.DeletedAt = &deletedAt
+	task.Version++
+	s.recordHistory(ctx, models.ActionDeleted, before, task)
+	return nil
+}
+
+// GetTrash lists the trashed tasks visible to the caller, most recently
+// deleted first.
+func (s *TaskService) GetTrash(ctx context.Context) ([]models.Task, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := s.visibleTasksFilter(ctx, userObjId)
+	if err != nil {
+		return nil, err
+	}
+	filter["deleted_at"] = bson.M{"$ne": nil}
+
+	tasks, err := s.Repo.GetTasks(ctx, filter, bson.D{{Key: "deleted_at", Value: -1}}, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting tasks", nil)
+	}
+	return tasks, nil
+}
+
+// RestoreTask takes a task out of the trash together with the subtasks that
+// were trashed along with it. Tasks whose project has since been deleted are
+// restored to their owner's inbox. ifMatch is checked against the task
+// itself, not its subtasks.
+func (s *TaskService) RestoreTask(ctx context.Context, id primitive.ObjectID, ifMatch []int) (*models.Task, error) {
+	task, err := s.anyTaskWithRole(ctx, id, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	err = checkVersion(task, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+	if task.DeletedAt == nil {
+		return nil, utils.BadRequest("Task is not in the trash", nil)
+	}
+
+	if task.ParentID != nil {
+		parent, err := s.Repo.GetTaskByID(ctx, *task.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.DeletedAt != nil {
+			return nil, utils.Conflict("Restore the parent task first", nil)
+		}
+	}
+
+	descendants, err := s.descendants(ctx, task.ID, task.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range append([]primitive.ObjectID{task.ID}, descendants...) {
+		err = s.restoreTask(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.Repo.GetTaskByID(ctx, task.ID)
+}
+
+func (s *TaskService) restoreTask(ctx context.Context, id primitive.ObjectID) error {
+	task, err := s.Repo.GetTaskByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := cloneTask(task)
+
+	err = s.Repo.RestoreTask(ctx, id, task.Version)
+	if err != nil {
+		return err
+	}
+	task.DeletedAt = nil
+	task.Version++
+
+	if task.ProjectID != nil {
+		if _, err := s.Projects.GetProjectByID(ctx, *task.ProjectID); err != nil {
+			inbox, err := s.Projects.EnsureInbox(ctx, task.UserID)
+			if err != nil {
+				return err
+			}
+			task, err = s.Repo.UpdateTask(ctx, task.ID, task.Version, bson.M{"project_id": inbox.ID}, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	s.recordHistory(ctx, models.ActionRestored, before, task)
+	return nil
+}
+
+// PurgeTrash permanently removes tasks, and their comments, that have been
+// in the trash for longer than retention.
+func (s *TaskService) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	ids, err := s.Repo.PurgeTasks(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err = s.Comments.DeleteTaskComments(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// RunPurger calls PurgeTrash every interval until ctx is cancelled.
+func (s *TaskService) RunPurger(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.PurgeTrash(ctx, retention)
+		if err != nil {
+			log.Println("Error purging trash:", err)
+		} else if n > 0 {
+			log.Printf("Purged %d tasks from the trash\n", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// descendants returns the ids of every task below id, parents before
+// children. Only tasks whose deleted_at equals deletedAt are followed, so nil
+// walks the live subtree and a trash timestamp walks what was trashed with it.
+func (s *TaskService) descendants(ctx context.Context, id primitive.ObjectID, deletedAt *time.Time) ([]primitive.ObjectID, error) {
+	ids := []primitive.ObjectID{}
+	level := []primitive.ObjectID{id}
+	for len(level) > 0 {
+		children, err := s.Repo.GetTasks(ctx, bson.M{"parent_id": bson.M{"$in": level}, "deleted_at": deletedAt}, nil, 0, 0)
+		if err != nil {
+			return nil, utils.Internal("Error getting subtasks", nil)
+		}
+		level = level[:0:0]
+		for _, c := range children {
+			ids = append(ids, c.ID)
+			level = append(level, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+// taskProject resolves the project a task is being placed in. The caller
+// must be able to edit the project and it must not be archived.
+func (s *TaskService) taskProject(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	projectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.BadRequest("Invalid project id", nil)
+	}
+
+	project, err := projectWithRole(ctx, s.Projects, projectId, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if project.Archived {
+		return nil, utils.BadRequest("Cannot add tasks to an archived project", nil)
+	}
+	return &project.ID, nil
+}
+
+// checkVersion fails with 412 Precondition Failed unless ifMatch is empty or
+// holds the task's current version.
+func checkVersion(task *models.Task, ifMatch []int) error {
+	if len(ifMatch) == 0 || slices.Contains(ifMatch, task.Version) {
+		return nil
+	}
+	return utils.PreconditionFailed("Task has changed since it was read", map[string]int{"version": task.Version})
+}
+
+// taskWithRole loads a task that is not in the trash and checks that the
+// caller may access it with at least the given role.
+func (s *TaskService) taskWithRole(ctx context.Context, id primitive.ObjectID, need string) (*models.Task, error) {
+	task, err := s.anyTaskWithRole(ctx, id, need)
+	if err != nil {
+		return nil, err
+	}
+	if task.DeletedAt != nil {
+		return nil, utils.NotFound("Task not found", nil)
+	}
+	return task, nil
+}
+
+// anyTaskWithRole loads a task, trashed or not, and checks that the caller
+// may access it with at least the given role. Owners of a task always may and
+// assignees act as editors; everyone else needs that role on the task's
+// project.
+func (s *TaskService) anyTaskWithRole(ctx context.Context, id primitive.ObjectID, need string) (*models.Task, error) {
+	task, err := s.Repo.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if task.UserID == userObjId {
+		return task, nil
+	}
+	if slices.Contains(task.AssigneeIDs, userObjId) && models.RoleAtLeast(models.RoleEditor, need) {
+		return task, nil
+	}
+
+	if task.ProjectID != nil {
+		project, err := s.Projects.GetProjectByID(ctx, *task.ProjectID)
+		if err == nil && models.RoleAtLeast(project.RoleOf(userObjId), need) {
+			return task, nil
+		}
+	}
+	return nil, utils.Unauthorized("Unauthorized access", nil)
+}
+
+// visibleTasksFilter matches the caller's own tasks, tasks assigned to them
+// and every task in a project they own or are a member of.
+func (s *TaskService) visibleTasksFilter(ctx context.Context, userID primitive.ObjectID) (bson.M, error) {
+	projects, err := s.Projects.GetProjects(ctx, bson.M{"$or": []bson.M{
+		{"owner_id": userID},
+		{"members.user_id": userID},
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []bson.M{
+		{"user_id": userID},
+		{"assignee_ids": userID},
+	}
+	if len(projects) > 0 {
+		ids := make([]primitive.ObjectID, len(projects))
+		for i, p := range projects {
+			ids[i] = p.ID
+		}
+		visible = append(visible, bson.M{"project_id": bson.M{"$in": ids}})
+	}
+
+	// wrapped in $and so callers remain free to add their own $or
+	return bson.M{"$and": []bson.M{{"$or": visible}}, "deleted_at": nil}, nil
+}
+
+func currentUserID(ctx context.Context) (primitive.ObjectID, error) {
+	userObjId, err := primitive.ObjectIDFromHex(ctx.Value("user_id").(string))
+	if err != nil {
+		return primitive.NilObjectID, utils.Unauthorized("Unauthorized access", nil)
+	}
+	return userObjId, nil
 }
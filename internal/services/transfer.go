@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+
+	"task-manager/internal/models"
+	"task-manager/internal/transfer"
+	"task-manager/internal/utils"
+	"task-manager/internal/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MaxImportRows caps how many tasks one import may create.
+const MaxImportRows = 1000
+
+// ExportTasks walks every task matching filters, a page at a time, and
+// hands each page to emit. Paging parameters in filters are ignored and
+// subtasks are listed flat. Nothing is emitted if the filters are invalid.
+func (s *TaskService) ExportTasks(ctx context.Context, filters map[string]string, emit func([]models.Task) error) error {
+	filters = maps.Clone(filters)
+	filters["view"] = ""
+	filters["page"] = ""
+	filters["total"] = ""
+	filters["cursor"] = ""
+	filters["limit"] = strconv.Itoa(MaxTaskLimit)
+
+	for {
+		page, err := s.GetTasks(ctx, filters)
+		if err != nil {
+			return err
+		}
+		if err := emit(page.Tasks); err != nil {
+			return err
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		filters["cursor"] = page.NextCursor
+	}
+}
+
+// ImportTasks creates a task for each row, skipping rows that fail
+// validation and rows whose external id matches a task the caller can
+// already see, or an earlier row. A task's own id counts as its external
+// id, so re-importing an export creates nothing. With dryRun set rows are
+// checked but nothing is created.
+func (s *TaskService) ImportTasks(ctx context.Context, rows []transfer.Row, dryRun bool) (*models.ImportReport, error) {
+	if len(rows) > MaxImportRows {
+		return nil, utils.BadRequest("Import is limited to "+strconv.Itoa(MaxImportRows)+" rows", nil)
+	}
+
+	seen, err := s.knownExternalIDs(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{DryRun: dryRun, Total: len(rows), Rows: []models.ImportRowResult{}}
+	for _, row := range rows {
+		req := row.Record.Request()
+		result := models.ImportRowResult{Row: row.Line, ExternalID: req.ExternalID}
+
+		// a field that could not be decoded is only reported once
+		errs := row.Errors
+		if err := validation.Validate.Struct(req); err != nil {
+			for _, e := range utils.FormatValidationErrors(err) {
+				if !slices.ContainsFunc(row.Errors, func(d utils.ValidationErrType) bool { return d.Path == e.Path }) {
+					errs = append(errs, e)
+				}
+			}
+		}
+
+		switch {
+		case len(errs) > 0:
+			result.Status = models.ImportInvalid
+			result.Errors = errs
+		case req.ExternalID != "" && seen[req.ExternalID]:
+			result.Status = models.ImportDuplicate
+		case dryRun:
+			// the project is the one thing creating could still reject
+			if _, err := s.taskProject(ctx, req.ProjectID); err != nil {
+				importFailed(&result, err)
+			} else {
+				result.Status = models.ImportValid
+			}
+		default:
+			task, err := s.CreateTask(ctx, req)
+			if err != nil {
+				importFailed(&result, err)
+			} else {
+				result.Status = models.ImportCreated
+				result.ID = task.ID.Hex()
+			}
+		}
+
+		if result.Status != models.ImportInvalid && req.ExternalID != "" {
+			seen[req.ExternalID] = true
+		}
+		switch result.Status {
+		case models.ImportCreated:
+			report.Created++
+		case models.ImportValid:
+			report.Valid++
+		case models.ImportDuplicate:
+			report.Duplicates++
+		case models.ImportInvalid:
+			report.Invalid++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	return report, nil
+}
+
+// knownExternalIDs returns which of the rows' external ids already belong
+// to a task the caller can see, either as its external id or its id.
+func (s *TaskService) knownExternalIDs(ctx context.Context, rows []transfer.Row) (map[string]bool, error) {
+	known := map[string]bool{}
+	externalIDs := []string{}
+	ids := []primitive.ObjectID{}
+	for _, row := range rows {
+		if x := row.Record.ExternalID; x != "" {
+			externalIDs = append(externalIDs, x)
+			if id, err := primitive.ObjectIDFromHex(x); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(externalIDs) == 0 {
+		return known, nil
+	}
+
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := s.visibleTasksFilter(ctx, userObjId)
+	if err != nil {
+		return nil, err
+	}
+	filter["$or"] = []bson.M{
+		{"external_id": bson.M{"$in": externalIDs}},
+		{"_id": bson.M{"$in": ids}},
+	}
+
+	tasks, err := s.Repo.GetTasks(ctx, filter, nil, 0, 0)
+	if err != nil {
+		return nil, utils.Internal("Error getting tasks", nil)
+	}
+	for _, t := range tasks {
+		known[t.ID.Hex()] = true
+		if t.ExternalID != "" {
+			known[t.ExternalID] = true
+		}
+	}
+	return known, nil
+}
+
+// importFailed marks a row that was rejected while creating its task.
+func importFailed(result *models.ImportRowResult, err error) {
+	result.Status = models.ImportInvalid
+	if appErr, ok := err.(*utils.AppError); ok {
+		result.Error = appErr.Message
+		result.Errors = appErr.Errors
+	} else {
+		result.Error = "Internal Server error"
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+)
+
+func TestTrash(t *testing.T) {
+	e := newEnv(t)
+	comments := NewCommentService(e.stores.Comments, e.tasks)
+	ctx, _ := e.user("alice")
+	parent := e.task(ctx, "Plan")
+	child, err := e.tasks.CreateSubtask(ctx, parent.ID, &models.CreateTaskRequest{Title: "Outline", Description: "d", Category: "work", Priority: 1, Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.CreateComment(ctx, child.ID, &models.CommentRequest{Body: "Start here"}); err != nil {
+		t.Fatal(err)
+	}
+	e.task(ctx, "Keep")
+
+	if _, err := e.tasks.RestoreTask(ctx, parent.ID, nil); code(err) != http.StatusBadRequest {
+		t.Errorf("restoring a live task: %v", err)
+	}
+	if err := e.tasks.DeleteTask(ctx, parent.ID, true, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.tasks.GetTask(ctx, child.ID); code(err) != http.StatusNotFound {
+		t.Errorf("reading a trashed task: %v", err)
+	}
+	trash, err := e.tasks.GetTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := titles(trash); len(got) != 2 || !slices.Contains(got, "Plan") || !slices.Contains(got, "Outline") {
+		t.Errorf("trash %v", got)
+	}
+
+	// a subtask comes back with its parent, not on its own
+	if _, err := e.tasks.RestoreTask(ctx, child.ID, nil); code(err) != http.StatusConflict {
+		t.Errorf("restoring a subtask first: %v", err)
+	}
+	restored, err := e.tasks.RestoreTask(ctx, parent.ID, nil)
+	if err != nil || restored.DeletedAt != nil {
+		t.Fatalf("restored %+v, %v", restored, err)
+	}
+	if _, err := e.tasks.GetTask(ctx, child.ID); err != nil {
+		t.Errorf("subtask after restore: %v", err)
+	}
+
+	// purging only removes what has been in the trash past the retention
+	if err := e.tasks.DeleteTask(ctx, parent.ID, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := e.tasks.PurgeTrash(ctx, time.Hour); err != nil || n != 0 {
+		t.Errorf("purged %d, %v", n, err)
+	}
+	if n, err := e.tasks.PurgeTrash(ctx, -time.Second); err != nil || n != 2 {
+		t.Errorf("purged %d, %v", n, err)
+	}
+	if trash, _ := e.tasks.GetTrash(ctx); len(trash) != 0 {
+		t.Errorf("trash after purge %v", titles(trash))
+	}
+	if list, _ := e.stores.Comments.GetComments(ctx, child.ID); len(list) != 0 {
+		t.Errorf("comments after purge %+v", list)
+	}
+	if list, _ := e.tasks.GetTasks(ctx, nil); len(list.Tasks) != 1 {
+		t.Errorf("live tasks after purge %v", list)
+	}
+}
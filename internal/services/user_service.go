@@ -12,10 +12,10 @@ import (
 )
 
 type UserService struct {
-	Repo *repository.UserRepository
+	Repo repository.UserStore
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
+func NewUserService(repo repository.UserStore) *UserService {
 	return &UserService{
 		Repo: repo,
 	}
@@ -184,3 +184,73 @@ func (s *UserService) ResendVerificationEmail(ctx context.Context, email string)
 
 	return nil
 }
+
+// GetProfile returns the caller's account and settings.
+func (s *UserService) GetProfile(ctx context.Context) (*models.UserResponse, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.Repo.GetUserByID(ctx, userObjId)
+	if err != nil {
+		return nil, err
+	}
+	return profile(user), nil
+}
+
+// UpdateSettings changes the settings req names.
+func (s *UserService) UpdateSettings(ctx context.Context, req *models.UpdateSettingsRequest) (*models.UserResponse, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.Repo.GetUserByID(ctx, userObjId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ReminderWindowMinutes != nil {
+		user.Settings.ReminderWindowMinutes = req.ReminderWindowMinutes
+	}
+	if req.DigestFrequency != nil {
+		user.Settings.DigestFrequency = *req.DigestFrequency
+	}
+	if req.DigestTime != nil {
+		user.Settings.DigestTime = *req.DigestTime
+	}
+	if req.DigestWeekday != nil {
+		user.Settings.DigestWeekday = *req.DigestWeekday
+	}
+	if req.Timezone != nil {
+		user.Settings.Timezone = *req.Timezone
+	}
+
+	if err := s.Repo.UpdateSettings(ctx, user.ID, user.Settings); err != nil {
+		return nil, err
+	}
+	user.UpdatedAt = time.Now()
+	return profile(user), nil
+}
+
+// profile is the response for a user's own account, with every setting
+// filled in.
+func profile(user *models.User) *models.UserResponse {
+	settings := user.Settings.WithDefaults()
+
+	return &models.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Settings:  &settings,
+	}
+}
+
+// WithUser returns ctx acting as user, the way a request signed in as them
+// would, for work that does not come from such a request.
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	ctx = context.WithValue(ctx, "user_id", user.ID.Hex())
+	ctx = context.WithValue(ctx, "email", user.Email)
+	return context.WithValue(ctx, "username", user.Username)
+}
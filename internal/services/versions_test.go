@@ -0,0 +1,55 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"task-manager/internal/models"
+)
+
+// TestIfMatch checks that every task mutation refuses a stale If-Match and
+// leaves the task alone, and goes through with the current version.
+func TestIfMatch(t *testing.T) {
+	e := newEnv(t)
+	ctx, _ := e.user("alice")
+	_, bob := e.user("bob")
+	task := e.task(ctx, "Write")
+	blocker := e.task(ctx, "Research")
+
+	tests := []struct {
+		name  string
+		apply func(ifMatch []int) (*models.Task, error)
+	}{
+		{"add tags", func(m []int) (*models.Task, error) { return e.tasks.AddTags(ctx, task.ID, []string{"docs"}, m) }},
+		{"remove tag", func(m []int) (*models.Task, error) { return e.tasks.RemoveTag(ctx, task.ID, "docs", m) }},
+		{"add dependency", func(m []int) (*models.Task, error) { return e.tasks.AddDependency(ctx, task.ID, blocker.ID, m) }},
+		{"remove dependency", func(m []int) (*models.Task, error) { return e.tasks.RemoveDependency(ctx, task.ID, blocker.ID, m) }},
+		{"assign", func(m []int) (*models.Task, error) { return e.tasks.AssignTask(ctx, task.ID, bob.Email, m) }},
+		{"reassign", func(m []int) (*models.Task, error) { return e.tasks.ReassignTask(ctx, task.ID, []string{}, m) }},
+		{"assign again", func(m []int) (*models.Task, error) { return e.tasks.AssignTask(ctx, task.ID, bob.Email, m) }},
+		{"unassign", func(m []int) (*models.Task, error) { return e.tasks.UnassignTask(ctx, task.ID, bob.ID, m) }},
+		{"delete", func(m []int) (*models.Task, error) {
+			return nil, e.tasks.DeleteTask(ctx, task.ID, false, m)
+		}},
+		{"restore", func(m []int) (*models.Task, error) { return e.tasks.RestoreTask(ctx, task.ID, m) }},
+	}
+
+	version := task.Version
+	for _, tt := range tests {
+		if _, err := tt.apply([]int{version - 1}); code(err) != http.StatusPreconditionFailed {
+			t.Errorf("%s with a stale version: %v", tt.name, err)
+		}
+		if _, err := tt.apply([]int{version + 1, version}); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		version++
+	}
+
+	got, err := e.tasks.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != version || len(got.Tags) != 0 || len(got.BlockedBy) != 0 || len(got.AssigneeIDs) != 0 {
+		t.Errorf("task %+v at version %d", got, version)
+	}
+}
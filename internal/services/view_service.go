@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"task-manager/internal/models"
+	"task-manager/internal/repository"
+	"task-manager/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// builtinViews are the smart lists every user gets. Their dates are days in
+// the caller's time zone, like every relative date in a filter expression.
+var builtinViews = []models.SavedView{
+	{Key: "today", Name: "Today", Filters: map[string]string{"q": "due:today status!=completed"}, Sort: "due_date"},
+	{Key: "overdue", Name: "Overdue", Filters: map[string]string{"q": "due<today status!=completed"}, Sort: "due_date"},
+	{Key: "upcoming", Name: "Upcoming 7 days", Filters: map[string]string{"q": "due>=today due<+7d status!=completed"}, Sort: "due_date"},
+	{Key: "no-due-date", Name: "No due date", Filters: map[string]string{"q": "due:none status!=completed"}},
+}
+
+func init() {
+	for i := range builtinViews {
+		builtinViews[i].BuiltIn = true
+	}
+}
+
+// ViewService manages saved task listings. Views are private to their owner
+// and are run through TaskService.GetTasks, so they only ever show tasks
+// the caller can see at the time.
+type ViewService struct {
+	Repo  repository.ViewStore
+	Tasks *TaskService
+}
+
+func NewViewService(repo repository.ViewStore, tasks *TaskService) *ViewService {
+	return &ViewService{
+		Repo:  repo,
+		Tasks: tasks,
+	}
+}
+
+// GetViews lists the built-in views followed by the caller's own.
+func (s *ViewService) GetViews(ctx context.Context) ([]models.SavedView, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	views, err := s.Repo.GetViews(ctx, userObjId)
+	if err != nil {
+		return nil, err
+	}
+	return append(slices.Clone(builtinViews), views...), nil
+}
+
+// GetView returns a view by id, or a built-in view by key.
+func (s *ViewService) GetView(ctx context.Context, id string) (*models.SavedView, error) {
+	for _, v := range builtinViews {
+		if v.Key == id {
+			return &v, nil
+		}
+	}
+
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.BadRequest("Invalid view id", nil)
+	}
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := s.Repo.GetViewByID(ctx, objectId)
+	if err != nil {
+		return nil, err
+	}
+	if view.OwnerID != userObjId {
+		return nil, utils.NotFound("View not found", nil)
+	}
+	return view, nil
+}
+
+func (s *ViewService) CreateView(ctx context.Context, req *models.SavedViewRequest) (*models.SavedView, error) {
+	userObjId, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &models.SavedView{OwnerID: userObjId}
+	err = s.apply(ctx, view, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Repo.CreateView(ctx, view)
+	if err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+func (s *ViewService) UpdateView(ctx context.Context, id string, req *models.SavedViewRequest) (*models.SavedView, error) {
+	view, err := s.ownView(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(ctx, view, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.UpdateView(ctx, view)
+}
+
+func (s *ViewService) DeleteView(ctx context.Context, id string) error {
+	view, err := s.ownView(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.Repo.DeleteView(ctx, view.ID)
+}
+
+// GetViewTasks runs a view. paging holds the limit, page, cursor and total
+// parameters of the request, which views do not store.
+func (s *ViewService) GetViewTasks(ctx context.Context, id string, paging map[string]string) (*models.TaskPage, error) {
+	view, err := s.GetView(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := viewFilters(view)
+	for k, v := range paging {
+		if v != "" {
+			filters[k] = v
+		}
+	}
+	return s.Tasks.GetTasks(ctx, filters)
+}
+
+// ownView loads a view the caller may change.
+func (s *ViewService) ownView(ctx context.Context, id string) (*models.SavedView, error) {
+	view, err := s.GetView(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if view.BuiltIn {
+		return nil, utils.Forbidden("Built-in views cannot be changed", nil)
+	}
+	return view, nil
+}
+
+// apply copies req onto view after checking that the view would run, so a
+// broken filter is reported when it is saved rather than every time the
+// view is opened.
+func (s *ViewService) apply(ctx context.Context, view *models.SavedView, req *models.SavedViewRequest) error {
+	errs := []utils.ValidationErrType{}
+	for _, key := range slices.Sorted(maps.Keys(req.Filters)) {
+		if !slices.Contains(models.ViewFilters, key) {
+			errs = append(errs, utils.ValidationErrType{
+				Path:    "filters." + key,
+				Message: fmt.Sprintf("filters.%s is not a task filter", key),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return utils.BadRequest("Validation Failed", errs)
+	}
+
+	view.Name = req.Name
+	view.Filters = req.Filters
+	if view.Filters == nil {
+		view.Filters = map[string]string{}
+	}
+	view.Sort = req.Sort
+	view.Order = req.Order
+
+	probe := viewFilters(view)
+	probe["limit"] = "1"
+	_, err := s.Tasks.GetTasks(ctx, probe)
+	return err
+}
+
+func viewFilters(view *models.SavedView) map[string]string {
+	filters := maps.Clone(view.Filters)
+	if filters == nil {
+		filters = map[string]string{}
+	}
+	filters["sort"] = view.Sort
+	filters["order"] = view.Order
+	return filters
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+)
+
+// TestBuiltinViewsTimeZone checks that Today, Overdue and Upcoming follow the
+// caller's day, which is most of a day ahead of UTC here.
+func TestBuiltinViewsTimeZone(t *testing.T) {
+	e := newEnv(t)
+	views := NewViewService(e.stores.Views, e.tasks)
+	ctx, user := e.user("alice")
+	err := e.stores.Users.UpdateSettings(context.Background(), user.ID, models.UserSettings{Timezone: "Pacific/Kiritimati"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+
+	now := time.Now().In(loc)
+	day := func(offset, hour int) func(*models.CreateTaskRequest) {
+		due := time.Date(now.Year(), now.Month(), now.Day()+offset, hour, 30, 0, 0, loc)
+		return func(r *models.CreateTaskRequest) { r.DueDate = due.Format(time.RFC3339) }
+	}
+	e.task(ctx, "Yesterday late", day(-1, 23))
+	e.task(ctx, "Today early", day(0, 0))
+	e.task(ctx, "Today late", day(0, 23))
+	e.task(ctx, "Tomorrow early", day(1, 0))
+	e.task(ctx, "Next week", day(8, 12))
+
+	tests := []struct {
+		view string
+		want []string
+	}{
+		{"overdue", []string{"Yesterday late"}},
+		{"today", []string{"Today early", "Today late"}},
+		{"upcoming", []string{"Today early", "Today late", "Tomorrow early"}},
+	}
+	for _, tt := range tests {
+		page, err := views.GetViewTasks(ctx, tt.view, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(page.Tasks); !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.view, got, tt.want)
+		}
+	}
+}
+
+func TestSavedViews(t *testing.T) {
+	e := newEnv(t)
+	views := NewViewService(e.stores.Views, e.tasks)
+	ctx, _ := e.user("alice")
+	bobCtx, _ := e.user("bob")
+	e.task(ctx, "Urgent", func(r *models.CreateTaskRequest) { r.Priority = 5 })
+	e.task(ctx, "Later", func(r *models.CreateTaskRequest) { r.Priority = 1 })
+
+	if _, err := views.CreateView(ctx, &models.SavedViewRequest{Name: "Broken", Filters: map[string]string{"q": "priority>>"}}); code(err) != http.StatusBadRequest {
+		t.Errorf("broken filter: %v", err)
+	}
+	if _, err := views.CreateView(ctx, &models.SavedViewRequest{Name: "Unknown", Filters: map[string]string{"colour": "red"}}); code(err) != http.StatusBadRequest {
+		t.Errorf("unknown filter: %v", err)
+	}
+
+	view, err := views.CreateView(ctx, &models.SavedViewRequest{Name: "Important", Filters: map[string]string{"q": "priority>=3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := views.GetViewTasks(ctx, view.ID.Hex(), nil)
+	if err != nil || !slices.Equal(titles(page.Tasks), []string{"Urgent"}) {
+		t.Errorf("view tasks %v, %v", page, err)
+	}
+
+	if _, err := views.GetView(bobCtx, view.ID.Hex()); code(err) != http.StatusNotFound {
+		t.Errorf("someone else's view: %v", err)
+	}
+	if err := views.DeleteView(ctx, "today"); code(err) != http.StatusForbidden {
+		t.Errorf("deleting a built-in view: %v", err)
+	}
+	all, err := views.GetViews(ctx)
+	if err != nil || len(all) != len(builtinViews)+1 || all[len(all)-1].Name != "Important" {
+		t.Errorf("views %+v, %v", all, err)
+	}
+}
@@ -0,0 +1,228 @@
+package taskquery
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindEnum
+	kindInt
+	kindDate
+	kindTag
+	kindID
+)
+
+type field struct {
+	key    string
+	kind   kind
+	values []string
+}
+
+// fields maps the names a query may use to task fields.
+var fields = map[string]field{
+	"status":   {key: "status", kind: kindEnum, values: []string{"pending", "in_progress", "completed"}},
+	"category": {key: "category", kind: kindString},
+	"priority": {key: "priority", kind: kindInt},
+	"tag":      {key: "tags", kind: kindTag},
+	"project":  {key: "project_id", kind: kindID},
+	"parent":   {key: "parent_id", kind: kindID},
+	"due":      {key: "due_date", kind: kindDate},
+	"created":  {key: "created_at", kind: kindDate},
+	"updated":  {key: "updated_at", kind: kindDate},
+}
+
+// none matches tasks without a value, as in "due:none" or "project:none"
+const none = "none"
+
+// Compile translates a parsed query into a task filter. Dates are whole
+// days in now's location unless given in RFC 3339 form, and may also be
+// written today, tomorrow, yesterday or as a number of days from today
+// such as +7d or -1d.
+func Compile(n Node, now time.Time) (bson.M, error) {
+	switch n := n.(type) {
+	case And:
+		return compileAll("$and", n.Nodes, now)
+	case Or:
+		return compileAll("$or", n.Nodes, now)
+	case Not:
+		m, err := Compile(n.Node, now)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": []bson.M{m}}, nil
+	case Cond:
+		return compileCond(n, now)
+	}
+	return nil, fmt.Errorf("unknown node %T", n)
+}
+
+func compileAll(op string, nodes []Node, now time.Time) (bson.M, error) {
+	all := make([]bson.M, len(nodes))
+	for i, n := range nodes {
+		m, err := Compile(n, now)
+		if err != nil {
+			return nil, err
+		}
+		all[i] = m
+	}
+	return bson.M{op: all}, nil
+}
+
+func compileCond(c Cond, now time.Time) (bson.M, error) {
+	f, ok := fields[c.Field]
+	if !ok {
+		return nil, &Error{Pos: c.Pos, Msg: fmt.Sprintf("unknown field %s, expected one of: %s", c.Field, fieldNames())}
+	}
+	invalid := func(format string, args ...any) error {
+		return &Error{Pos: c.ValuePos, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	// != is the negation of equality, so tasks without the field match it
+	if c.Op == "!=" {
+		eq := c
+		eq.Op = ":"
+		m, err := compileCond(eq, now)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": []bson.M{m}}, nil
+	}
+
+	equal := c.Op == ":" || c.Op == "="
+	if !equal && f.kind != kindInt && f.kind != kindDate {
+		return nil, &Error{Pos: c.ValuePos - len(c.Op), Msg: fmt.Sprintf("%s cannot be compared with %s", c.Field, c.Op)}
+	}
+
+	if strings.EqualFold(c.Value, none) && (f.kind == kindDate || f.kind == kindID || f.kind == kindTag) {
+		if !equal {
+			return nil, invalid("%s cannot be compared with none", c.Field)
+		}
+		if f.kind == kindTag {
+			return bson.M{"$or": []bson.M{{f.key: nil}, {f.key: bson.M{"$size": 0}}}}, nil
+		}
+		if f.kind == kindDate {
+			// a zero date is no date
+			return bson.M{"$or": []bson.M{{f.key: nil}, {f.key: time.Time{}}}}, nil
+		}
+		return bson.M{f.key: nil}, nil
+	}
+
+	switch f.kind {
+	case kindString:
+		return bson.M{f.key: c.Value}, nil
+
+	case kindEnum:
+		if !slices.Contains(f.values, c.Value) {
+			return nil, invalid("%s must be one of: %s", c.Field, strings.Join(f.values, ", "))
+		}
+		return bson.M{f.key: c.Value}, nil
+
+	case kindTag:
+		return bson.M{f.key: strings.ToLower(c.Value)}, nil
+
+	case kindID:
+		id, err := primitive.ObjectIDFromHex(c.Value)
+		if err != nil {
+			return nil, invalid("%s must be an id or none", c.Field)
+		}
+		return bson.M{f.key: id}, nil
+
+	case kindInt:
+		v, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, invalid("%s must be a whole number", c.Field)
+		}
+		if equal {
+			return bson.M{f.key: v}, nil
+		}
+		return bson.M{f.key: bson.M{comparisons[c.Op]: v}}, nil
+
+	case kindDate:
+		from, to, err := parseDate(c.Value, now)
+		if err != nil {
+			return nil, invalid("%s must be a date (2006-01-02), an RFC 3339 time, today, tomorrow, yesterday, +Nd, -Nd or none", c.Field)
+		}
+		// a day is the range [from, to); an exact time has from == to
+		switch c.Op {
+		case ":", "=":
+			if from.Equal(to) {
+				return bson.M{f.key: from}, nil
+			}
+			return bson.M{f.key: bson.M{"$gte": from, "$lt": to}}, nil
+		// the zero date that stands for no date is not before anything
+		case "<":
+			return bson.M{f.key: bson.M{"$gt": time.Time{}, "$lt": from}}, nil
+		case ">=":
+			return bson.M{f.key: bson.M{"$gte": from}}, nil
+		case "<=":
+			if from.Equal(to) {
+				return bson.M{f.key: bson.M{"$gt": time.Time{}, "$lte": from}}, nil
+			}
+			return bson.M{f.key: bson.M{"$gt": time.Time{}, "$lt": to}}, nil
+		case ">":
+			if from.Equal(to) {
+				return bson.M{f.key: bson.M{"$gt": from}}, nil
+			}
+			return bson.M{f.key: bson.M{"$gte": to}}, nil
+		}
+	}
+	return nil, &Error{Pos: c.ValuePos - len(c.Op), Msg: fmt.Sprintf("%s cannot be compared with %s", c.Field, c.Op)}
+}
+
+var comparisons = map[string]string{
+	">":  "$gt",
+	">=": "$gte",
+	"<":  "$lt",
+	"<=": "$lte",
+}
+
+// parseDate returns the day s names as [from, to), or from == to for an
+// exact RFC 3339 time.
+func parseDate(s string, now time.Time) (time.Time, time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, t, nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var day time.Time
+	switch s = strings.ToLower(s); {
+	case s == "today":
+		day = today
+	case s == "tomorrow":
+		day = today.AddDate(0, 0, 1)
+	case s == "yesterday":
+		day = today.AddDate(0, 0, -1)
+	case (strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-")) && strings.HasSuffix(s, "d"):
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		day = today.AddDate(0, 0, n)
+	default:
+		t, err := time.ParseInLocation(time.DateOnly, s, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		day = t
+	}
+	return day, day.AddDate(0, 0, 1), nil
+}
+
+func fieldNames() string {
+	names := []string{}
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,295 @@
+package taskquery
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// limits that keep a query cheap to compile and run
+const (
+	MaxConditions = 64
+	MaxDepth      = 16
+)
+
+// Node is a node of a parsed query: And, Or, Not or Cond.
+type Node interface {
+	node()
+}
+
+// And matches when every node matches. Conditions written next to each
+// other, or joined by AND, are combined this way.
+type And struct {
+	Nodes []Node
+}
+
+// Or matches when any node matches.
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches when Node does not. It is written as a leading "-" or NOT.
+type Not struct {
+	Node Node
+}
+
+// Cond is a single comparison such as "priority>=3". Pos and ValuePos are
+// the offsets of the field and the value in the query, in characters.
+type Cond struct {
+	Field    string
+	Op       string
+	Value    string
+	Pos      int
+	ValuePos int
+}
+
+func (And) node()  {}
+func (Or) node()   {}
+func (Not) node()  {}
+func (Cond) node() {}
+
+// Error is a parse or compile error at a character offset in the query.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// operators, longest first so ">=" is not read as ">"
+var operators = []string{"!=", ">=", "<=", ":", "=", ">", "<"}
+
+// Parse parses a query such as
+//
+//	status:pending priority>=3 due<2026-11-01 (tag:infra OR tag:ops) -category:personal
+//
+// Conditions are field, operator and value; values containing spaces are
+// quoted. Adjacent conditions must all match, OR offers alternatives and
+// binds looser than AND, and "-" or NOT negates. Parentheses group.
+func Parse(s string) (Node, error) {
+	p := &parser{src: []rune(s)}
+	n, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	p.space()
+	if !p.eof() {
+		if p.src[p.pos] == ')' {
+			return nil, p.errorf("unexpected )")
+		}
+		return nil, p.errorf("unexpected %q", string(p.src[p.pos]))
+	}
+	return n, nil
+}
+
+type parser struct {
+	src   []rune
+	pos   int
+	conds int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) space() {
+	for !p.eof() && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) *Error {
+	return &Error{Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// keyword consumes word if it comes next as a whole word.
+func (p *parser) keyword(word string) bool {
+	p.space()
+	end := p.pos + len(word)
+	if end > len(p.src) || string(p.src[p.pos:end]) != word {
+		return false
+	}
+	if end < len(p.src) && !unicode.IsSpace(p.src[end]) && p.src[end] != '(' && p.src[end] != ')' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) or(depth int) (Node, error) {
+	first, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for p.keyword("OR") {
+		n, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) and(depth int) (Node, error) {
+	nodes := []Node{}
+	for {
+		p.space()
+		if p.eof() || p.src[p.pos] == ')' {
+			break
+		}
+		start := p.pos
+		if p.keyword("OR") {
+			p.pos = start
+			break
+		}
+		if p.keyword("AND") {
+			if len(nodes) == 0 {
+				p.pos = start
+				return nil, p.errorf("AND needs a condition before it")
+			}
+			p.space()
+			next := p.pos
+			if p.eof() || p.src[p.pos] == ')' || p.keyword("OR") || p.keyword("AND") {
+				p.pos = next
+				return nil, p.errorf("AND needs a condition after it")
+			}
+		}
+
+		n, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil, p.errorf("expected a condition")
+	case 1:
+		return nodes[0], nil
+	}
+	return And{Nodes: nodes}, nil
+}
+
+func (p *parser) unary(depth int) (Node, error) {
+	p.space()
+	if !p.eof() && p.src[p.pos] == '-' {
+		p.pos++
+		n, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: n}, nil
+	}
+	if p.keyword("NOT") {
+		n, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: n}, nil
+	}
+	return p.primary(depth)
+}
+
+func (p *parser) primary(depth int) (Node, error) {
+	p.space()
+	if p.eof() {
+		return nil, p.errorf("expected a condition")
+	}
+
+	if p.src[p.pos] == '(' {
+		open := p.pos
+		if depth == MaxDepth {
+			return nil, p.errorf("parentheses nested more than %d deep", MaxDepth)
+		}
+		p.pos++
+		n, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		p.space()
+		if p.eof() || p.src[p.pos] != ')' {
+			return nil, &Error{Pos: open, Msg: "missing closing parenthesis"}
+		}
+		p.pos++
+		return n, nil
+	}
+
+	return p.cond()
+}
+
+func (p *parser) cond() (Node, error) {
+	c := Cond{Pos: p.pos}
+	for !p.eof() && (unicode.IsLetter(p.src[p.pos]) || p.src[p.pos] == '_') {
+		p.pos++
+	}
+	c.Field = strings.ToLower(string(p.src[c.Pos:p.pos]))
+	if c.Field == "" {
+		return nil, p.errorf("expected a field name")
+	}
+
+	for _, op := range operators {
+		end := p.pos + len(op)
+		if end <= len(p.src) && string(p.src[p.pos:end]) == op {
+			c.Op = op
+			p.pos = end
+			break
+		}
+	}
+	if c.Op == "" {
+		return nil, p.errorf("expected an operator after %s", c.Field)
+	}
+
+	c.ValuePos = p.pos
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, p.errorf("expected a value after %s%s", c.Field, c.Op)
+	}
+	c.Value = value
+
+	p.conds++
+	if p.conds > MaxConditions {
+		return nil, &Error{Pos: c.Pos, Msg: fmt.Sprintf("more than %d conditions", MaxConditions)}
+	}
+	return c, nil
+}
+
+// value reads a quoted or bare value. Bare values end at a space or a
+// closing parenthesis; quoted values may contain \" and \\.
+func (p *parser) value() (string, error) {
+	if p.eof() || p.src[p.pos] != '"' {
+		start := p.pos
+		for !p.eof() && !unicode.IsSpace(p.src[p.pos]) && p.src[p.pos] != ')' && p.src[p.pos] != '(' {
+			p.pos++
+		}
+		return string(p.src[start:p.pos]), nil
+	}
+
+	open := p.pos
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		r := p.src[p.pos]
+		p.pos++
+		switch {
+		case r == '"':
+			return b.String(), nil
+		case r == '\\' && !p.eof():
+			b.WriteRune(p.src[p.pos])
+			p.pos++
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", &Error{Pos: open, Msg: "unterminated quoted value"}
+}
@@ -0,0 +1,164 @@
+package taskquery
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// format writes a node as a compact s-expression.
+func format(n Node) string {
+	join := func(op string, nodes []Node) string {
+		parts := []string{op}
+		for _, n := range nodes {
+			parts = append(parts, format(n))
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	}
+	switch n := n.(type) {
+	case And:
+		return join("and", n.Nodes)
+	case Or:
+		return join("or", n.Nodes)
+	case Not:
+		return "(not " + format(n.Node) + ")"
+	case Cond:
+		return n.Field + n.Op + n.Value
+	}
+	return "?"
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"status:pending", "status:pending"},
+		{"  priority>=3  ", "priority>=3"},
+		{"Status:pending", "status:pending"},
+		{"status:pending priority>3", "(and status:pending priority>3)"},
+		{"status:pending AND priority>3", "(and status:pending priority>3)"},
+		{"tag:a OR tag:b tag:c", "(or tag:a (and tag:b tag:c))"},
+		{"(tag:a OR tag:b) tag:c", "(and (or tag:a tag:b) tag:c)"},
+		{"-category:personal", "(not category:personal)"},
+		{"NOT (tag:a OR tag:b)", "(not (or tag:a tag:b))"},
+		{`category:"side projects"`, "category:side projects"},
+		{`category:"say \"hi\""`, `category:say "hi"`},
+		{"due<=2026-11-01 due!=none", "(and due<=2026-11-01 due!=none)"},
+		{"(tag:a AND tag:b)", "(and tag:a tag:b)"},
+		{"ORDER:x", "order:x"},
+	}
+
+	for _, tt := range tests {
+		n, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		if got := format(n); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{"", 0},
+		{"status", 6},
+		{"status:", 7},
+		{"status:pending)", 14},
+		{"(status:pending", 0},
+		{`category:"open`, 9},
+		{"tag:a OR", 8},
+		{"AND tag:a", 0},
+		{"tag:a AND", 9},
+		{"tag:a AND OR tag:b", 10},
+		{"(tag:a AND)", 10},
+		{":x", 0},
+		{strings.Repeat("(", MaxDepth+1) + "tag:a" + strings.Repeat(")", MaxDepth+1), MaxDepth},
+		{strings.Repeat("tag:a ", MaxConditions+1), 6 * MaxConditions},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		var qerr *Error
+		if !errors.As(err, &qerr) {
+			t.Errorf("%q: expected a query error, got %v", tt.query, err)
+			continue
+		}
+		if qerr.Pos != tt.pos {
+			t.Errorf("%q: %v, want position %d", tt.query, err, tt.pos)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, time.UTC)
+	today := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		query string
+		want  bson.M
+	}{
+		{"status:pending", bson.M{"status": "pending"}},
+		{"priority>=3", bson.M{"priority": bson.M{"$gte": 3}}},
+		{"priority=3", bson.M{"priority": 3}},
+		{"tag:Infra", bson.M{"tags": "infra"}},
+		{"tag:none", bson.M{"$or": []bson.M{{"tags": nil}, {"tags": bson.M{"$size": 0}}}}},
+		{"project:" + id.Hex(), bson.M{"project_id": id}},
+		{"project:none", bson.M{"project_id": nil}},
+		{"category!=work", bson.M{"$nor": []bson.M{{"category": "work"}}}},
+		{"due:today", bson.M{"due_date": bson.M{"$gte": today, "$lt": tomorrow}}},
+		{"due<today", bson.M{"due_date": bson.M{"$gt": time.Time{}, "$lt": today}}},
+		{"due<=today", bson.M{"due_date": bson.M{"$gt": time.Time{}, "$lt": tomorrow}}},
+		{"due>today", bson.M{"due_date": bson.M{"$gte": tomorrow}}},
+		{"due>=+1d", bson.M{"due_date": bson.M{"$gte": tomorrow}}},
+		{"due:none", bson.M{"$or": []bson.M{{"due_date": nil}, {"due_date": time.Time{}}}}},
+		{"status:pending -tag:x", bson.M{"$and": []bson.M{{"status": "pending"}, {"$nor": []bson.M{{"tags": "x"}}}}}},
+	}
+
+	for _, tt := range tests {
+		n, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.query, err)
+		}
+		got, err := Compile(n, now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, query := range []string{
+		"colour:red",
+		"status:done",
+		"priority:high",
+		"category>work",
+		"due:someday",
+		"due>none",
+		"project:42",
+	} {
+		n, err := Parse(query)
+		if err != nil {
+			t.Fatalf("%q: %v", query, err)
+		}
+		if _, err := Compile(n, time.Now()); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
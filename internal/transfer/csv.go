@@ -0,0 +1,141 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+)
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) Encode(tasks []models.Task) error {
+	if !e.header {
+		e.header = true
+		if err := e.w.Write(Columns); err != nil {
+			return err
+		}
+	}
+	for i := range tasks {
+		r := NewRecord(&tasks[i])
+		err := e.w.Write([]string{
+			r.ID, csvText(r.ExternalID), csvText(r.Title), csvText(r.Description), csvText(r.Category),
+			csvText(strings.Join(r.Tags, ",")), strconv.Itoa(r.Priority), r.Status,
+			r.DueDate, r.Recurrence, r.ProjectID, r.CreatedAt, r.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	return e.Encode(nil)
+}
+
+// csvFormulaStart are the first characters that make spreadsheets read a
+// cell as a formula.
+const csvFormulaStart = "=+-@\t\r"
+
+// csvText quotes user text that a spreadsheet would run as a formula with a
+// leading ', which spreadsheets hide. Text that csvUnquote would change is
+// quoted too, so that it reads back as it was.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaStart, rune(s[0])) || csvUnquote(s) != s {
+		return "'" + s
+	}
+	return s
+}
+
+// csvUnquote undoes csvText.
+func csvUnquote(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(csvFormulaStart+"'", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// decodeCSV reads a CSV file whose first line names the columns. Columns
+// may come in any order and all but title may be left out. Text quoted by
+// csvText reads back as it was.
+func decodeCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(Columns, name) {
+			return nil, fmt.Errorf("unknown column %q, expected any of: %s", name, strings.Join(Columns, ", "))
+		}
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("column %q appears more than once", name)
+		}
+		index[name] = i
+	}
+	if _, ok := index["title"]; !ok {
+		return nil, errors.New("a title column is required")
+	}
+
+	rows := []Row{}
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		get := func(name string) string {
+			if i, ok := index[name]; ok {
+				return csvUnquote(strings.TrimSpace(fields[i]))
+			}
+			return ""
+		}
+
+		row := Row{Line: line}
+		row.Record = Record{
+			ExternalID:  get("external_id"),
+			Title:       get("title"),
+			Description: get("description"),
+			Category:    get("category"),
+			Status:      get("status"),
+			DueDate:     get("due_date"),
+			Recurrence:  get("recurrence"),
+			ProjectID:   get("project_id"),
+		}
+		if tags := get("tags"); tags != "" {
+			row.Record.Tags = strings.Split(tags, ",")
+		}
+		if p := get("priority"); p != "" {
+			row.Record.Priority, err = strconv.Atoi(p)
+			if err != nil {
+				row.Errors = append(row.Errors, utils.ValidationErrType{Path: "priority", Message: "priority must be a number"})
+			}
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,477 @@
+package transfer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+)
+
+// calendar components a task can be written as
+const (
+	ComponentTodo  = "VTODO"
+	ComponentEvent = "VEVENT"
+)
+
+// longest line, in bytes, before it is folded onto the next
+const icsLineBytes = 75
+
+// icsStatus maps task statuses to VTODO and VEVENT statuses. VEVENT has no
+// notion of progress, so only pending tasks are tentative.
+var icsStatus = map[string][2]string{
+	"pending":     {"NEEDS-ACTION", "TENTATIVE"},
+	"in_progress": {"IN-PROCESS", "CONFIRMED"},
+	"completed":   {"COMPLETED", "CONFIRMED"},
+}
+
+// icsImportStatus maps VTODO statuses to task statuses.
+var icsImportStatus = map[string]string{
+	"NEEDS-ACTION": "pending",
+	"IN-PROCESS":   "in_progress",
+	"COMPLETED":    "completed",
+	"CANCELLED":    "completed",
+}
+
+// icsPriority maps a task priority, 5 being the most urgent, onto the iCal
+// scale where 1 is the highest and 9 the lowest.
+func icsPriority(p int) int {
+	return 11 - 2*p
+}
+
+// taskPriority is the inverse of icsPriority. 0, undefined in iCal, is a
+// medium priority.
+func taskPriority(p int) int {
+	if p == 0 {
+		return 3
+	}
+	return 5 - (p-1)/2
+}
+
+// icsEncoder writes one VCALENDAR, a page of tasks at a time.
+type icsEncoder struct {
+	w         io.Writer
+	component string
+	rules     bool
+	started   bool
+}
+
+// NewCalendarEncoder returns an Encoder for a calendar feed, writing each
+// task as a component, VTODO or VEVENT. Tasks without a due date are left
+// out of VEVENT feeds, which need a start. Recurrence rules are left out
+// since every occurrence of a recurring task is a task of its own.
+func NewCalendarEncoder(w io.Writer, component string) Encoder {
+	return &icsEncoder{w: w, component: component}
+}
+
+func (e *icsEncoder) Encode(tasks []models.Task) error {
+	b := &icsWriter{}
+	if !e.started {
+		e.started = true
+		b.line("BEGIN:VCALENDAR")
+		b.line("VERSION:2.0")
+		b.line("PRODID:-//task-manager//tasks//EN")
+		b.line("CALSCALE:GREGORIAN")
+		b.line("X-WR-CALNAME:Tasks")
+	}
+	for i := range tasks {
+		e.encodeTask(b, &tasks[i])
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *icsEncoder) encodeTask(b *icsWriter, task *models.Task) {
+	event := e.component == ComponentEvent
+	if event && task.DueDate.IsZero() {
+		return
+	}
+	r := NewRecord(task)
+
+	b.line("BEGIN:" + e.component)
+	b.line("UID:" + icsText(r.ExternalID))
+	b.line("DTSTAMP:" + icsTime(task.UpdatedAt))
+	b.line("CREATED:" + icsTime(task.CreatedAt))
+	b.line("LAST-MODIFIED:" + icsTime(task.UpdatedAt))
+	b.line("SUMMARY:" + icsText(task.Title))
+	if task.Description != "" {
+		b.line("DESCRIPTION:" + icsText(task.Description))
+	}
+	categories := []string{}
+	for _, c := range append([]string{task.Category}, task.Tags...) {
+		if c != "" {
+			categories = append(categories, icsText(c))
+		}
+	}
+	if len(categories) > 0 {
+		b.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+	if task.Priority != 0 {
+		b.line("PRIORITY:" + strconv.Itoa(icsPriority(task.Priority)))
+	}
+
+	status := icsStatus[task.Status]
+	if event {
+		b.line("STATUS:" + status[1])
+		if allDay(task.DueDate) {
+			b.line("DTSTART;VALUE=DATE:" + icsDate(task.DueDate))
+			b.line("DTEND;VALUE=DATE:" + icsDate(task.DueDate.AddDate(0, 0, 1)))
+		} else {
+			b.line("DTSTART:" + icsTime(task.DueDate))
+		}
+	} else {
+		b.line("STATUS:" + status[0])
+		if task.Status == "completed" {
+			b.line("COMPLETED:" + icsTime(task.UpdatedAt))
+			b.line("PERCENT-COMPLETE:100")
+		}
+		if !task.DueDate.IsZero() {
+			if allDay(task.DueDate) {
+				b.line("DUE;VALUE=DATE:" + icsDate(task.DueDate))
+			} else {
+				b.line("DUE:" + icsTime(task.DueDate))
+			}
+		}
+		if e.rules && task.Recurrence != "" {
+			b.line("RRULE:" + strings.TrimPrefix(task.Recurrence, "RRULE:"))
+		}
+	}
+	b.line("END:" + e.component)
+}
+
+func (e *icsEncoder) Close() error {
+	if !e.started {
+		if err := e.Encode(nil); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(e.w, "END:VCALENDAR\r\n")
+	return err
+}
+
+// icsWriter collects content lines, folded and ended with CRLF.
+type icsWriter struct {
+	strings.Builder
+}
+
+func (b *icsWriter) line(s string) {
+	// continuation lines lose a byte to their leading space
+	limit := icsLineBytes
+	for len(s) > limit {
+		// never fold inside a UTF-8 sequence
+		n := limit
+		for n > 0 && s[n]&0xC0 == 0x80 {
+			n--
+		}
+		b.WriteString(s[:n])
+		b.WriteString("\r\n ")
+		s = s[n:]
+		limit = icsLineBytes - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
+
+// allDay reports whether t is a date without a time of day, which tasks
+// store as midnight UTC.
+func allDay(t time.Time) bool {
+	t = t.UTC()
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func icsDate(t time.Time) string {
+	return t.UTC().Format("20060102")
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icsText escapes s as an iCal TEXT value.
+func icsText(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// icsProperty is one unfolded content line, such as
+// "DUE;TZID=Europe/Paris:20261020T090000".
+type icsProperty struct {
+	Line   int
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// decodeICS reads the VTODO components of a calendar. Other components,
+// and components nested in a VTODO such as alarms, are skipped. Row.Line
+// is the line of the VTODO's BEGIN.
+func decodeICS(r io.Reader) ([]Row, error) {
+	props, err := readICS(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(props) == 0 || props[0].Name != "BEGIN" || !strings.EqualFold(props[0].Value, "VCALENDAR") {
+		return nil, errors.New("expected BEGIN:VCALENDAR")
+	}
+
+	rows := []Row{}
+	stack := []string{}
+	var todo []icsProperty
+	for _, p := range props {
+		switch p.Name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(p.Value))
+			if len(stack) == 2 && stack[1] == ComponentTodo {
+				todo = []icsProperty{p}
+			}
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1] != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("unexpected END:%s on line %d", p.Value, p.Line)
+			}
+			if len(stack) == 2 && stack[1] == ComponentTodo {
+				rows = append(rows, todoRow(todo))
+				todo = nil
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 2 && todo != nil {
+				todo = append(todo, p)
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1])
+	}
+	return rows, nil
+}
+
+// readICS splits a calendar into unfolded content lines.
+func readICS(r io.Reader) ([]icsProperty, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxICSLine)
+
+	props := []icsProperty{}
+	var current strings.Builder
+	start, line := 0, 0
+	flush := func() error {
+		if current.Len() == 0 {
+			return nil
+		}
+		p, err := parseICSLine(current.String())
+		if err != nil {
+			return fmt.Errorf("%w on line %d", err, start)
+		}
+		p.Line = start
+		props = append(props, p)
+		current.Reset()
+		return nil
+	}
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			current.WriteString(text[1:])
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		start = line
+		current.WriteString(text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// longest unfolded line readICS accepts
+const maxICSLine = 1 << 20
+
+// parseICSLine parses "NAME;PARAM=value;PARAM="quoted":value".
+func parseICSLine(s string) (icsProperty, error) {
+	p := icsProperty{Params: map[string]string{}}
+	quoted := false
+	colon := -1
+	for i, r := range s {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return p, errors.New("expected NAME:value")
+	}
+	p.Value = s[colon+1:]
+
+	parts := strings.Split(s[:colon], ";")
+	p.Name = strings.ToUpper(parts[0])
+	if p.Name == "" {
+		return p, errors.New("expected a property name")
+	}
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		p.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return p, nil
+}
+
+// todoRow converts one VTODO into a row. A VTODO without a description
+// uses its summary, and one without categories goes in the "calendar"
+// category, since tasks require both.
+func todoRow(props []icsProperty) Row {
+	row := Row{Line: props[0].Line}
+	rec := &row.Record
+	rec.Status = "pending"
+	rec.Priority = taskPriority(0)
+	invalid := func(path, message string) {
+		row.Errors = append(row.Errors, utils.ValidationErrType{Path: path, Message: message})
+	}
+
+	completed := false
+	status := ""
+	for _, p := range props[1:] {
+		switch p.Name {
+		case "UID":
+			rec.ExternalID = icsUnescape(p.Value)
+		case "SUMMARY":
+			rec.Title = icsUnescape(p.Value)
+		case "DESCRIPTION":
+			rec.Description = icsUnescape(p.Value)
+		case "CATEGORIES":
+			for _, c := range icsList(p.Value) {
+				if c == "" {
+					continue
+				}
+				if rec.Category == "" {
+					rec.Category = c
+				} else {
+					rec.Tags = append(rec.Tags, strings.ToLower(c))
+				}
+			}
+		case "PRIORITY":
+			n, err := strconv.Atoi(strings.TrimSpace(p.Value))
+			if err != nil || n < 0 || n > 9 {
+				invalid("priority", "priority must be a number from 0 to 9")
+				continue
+			}
+			rec.Priority = taskPriority(n)
+		case "STATUS":
+			status = strings.ToUpper(strings.TrimSpace(p.Value))
+		case "COMPLETED":
+			completed = true
+		case "PERCENT-COMPLETE":
+			completed = completed || strings.TrimSpace(p.Value) == "100"
+		case "DUE":
+			due, err := icsParseTime(p)
+			if err != nil {
+				invalid("due_date", "due_date "+err.Error())
+				continue
+			}
+			rec.DueDate = due.Format(time.RFC3339)
+		case "RRULE":
+			rec.Recurrence = p.Value
+		}
+	}
+
+	switch {
+	case status != "":
+		s, ok := icsImportStatus[status]
+		if !ok {
+			invalid("status", "status must be one of: NEEDS-ACTION IN-PROCESS COMPLETED CANCELLED")
+		}
+		rec.Status = s
+	case completed:
+		rec.Status = "completed"
+	}
+	if rec.Description == "" {
+		rec.Description = rec.Title
+	}
+	if rec.Category == "" {
+		rec.Category = "calendar"
+	}
+	return row
+}
+
+// icsParseTime reads a DATE or DATE-TIME value. Dates are midnight UTC,
+// times without a zone or TZID are taken as UTC.
+func icsParseTime(p icsProperty) (time.Time, error) {
+	v := strings.TrimSpace(p.Value)
+	if p.Params["VALUE"] == "DATE" || len(v) == len("20060102") {
+		t, err := time.Parse("20060102", v)
+		if err != nil {
+			return time.Time{}, errors.New("must be a date such as 20260102")
+		}
+		return t, nil
+	}
+
+	loc := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, errors.New("has an unknown time zone " + tzid)
+		}
+		loc = l
+	}
+	layout := "20060102T150405"
+	if strings.HasSuffix(v, "Z") {
+		layout += "Z"
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(layout, v, loc)
+	if err != nil {
+		return time.Time{}, errors.New("must be a time such as 20260102T150405Z")
+	}
+	return t.UTC(), nil
+}
+
+// icsList splits a comma separated TEXT list and unescapes each value.
+func icsList(s string) []string {
+	values := []string{}
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, icsUnescape(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, icsUnescape(s[start:]))
+}
+
+// icsUnescape reverses icsText.
+func icsUnescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+)
+
+// jsonEncoder writes one JSON array, a page of tasks at a time.
+type jsonEncoder struct {
+	w       io.Writer
+	started bool
+	count   int
+}
+
+func newJSONEncoder(w io.Writer) *jsonEncoder {
+	return &jsonEncoder{w: w}
+}
+
+func (e *jsonEncoder) Encode(tasks []models.Task) error {
+	var buf bytes.Buffer
+	if !e.started {
+		e.started = true
+		buf.WriteString("[")
+	}
+	for i := range tasks {
+		if e.count > 0 {
+			buf.WriteString(",")
+		}
+		e.count++
+		data, err := json.Marshal(NewRecord(&tasks[i]))
+		if err != nil {
+			return err
+		}
+		buf.WriteString("\n  ")
+		buf.Write(data)
+	}
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	if !e.started {
+		if err := e.Encode(nil); err != nil {
+			return err
+		}
+	}
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// decodeJSON reads an array of records. Unknown fields are ignored so a
+// file of full API tasks imports too, but each element must be an object
+// whose fields have the right types.
+func decodeJSON(r io.Reader) ([]Row, error) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&elements); err != nil {
+		return nil, errors.New("expected a JSON array of tasks")
+	}
+
+	rows := make([]Row, len(elements))
+	for i, el := range elements {
+		rows[i].Line = i + 1
+		if err := json.Unmarshal(el, &rows[i].Record); err != nil {
+			message := "must be a task object"
+			path := ""
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Field != "" {
+				path = typeErr.Field
+				message = path + " has the wrong type"
+			}
+			rows[i].Errors = append(rows[i].Errors, utils.ValidationErrType{Path: path, Message: message})
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,95 @@
+package transfer
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"task-manager/internal/models"
+)
+
+var (
+	checklistItem    = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	checklistHeading = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+)
+
+// markdownEncoder writes a GitHub-style checklist with a heading for each
+// category. Tasks come grouped when they are sorted by category; otherwise
+// a category gets a heading each time it comes up again.
+type markdownEncoder struct {
+	w        io.Writer
+	started  bool
+	category string
+}
+
+func (e *markdownEncoder) Encode(tasks []models.Task) error {
+	var b strings.Builder
+	for i := range tasks {
+		task := &tasks[i]
+		if !e.started || task.Category != e.category {
+			if e.started {
+				b.WriteString("\n")
+			}
+			e.started = true
+			e.category = task.Category
+			b.WriteString("## " + task.Category + "\n\n")
+		}
+
+		mark := "[ ]"
+		if task.Status == "completed" {
+			mark = "[x]"
+		}
+		parts := append([]string{"-", mark}, todoLead(task)...)
+		parts = append(parts, todoText(task, false)...)
+		b.WriteString(strings.Join(parts, " ") + "\n")
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *markdownEncoder) Close() error {
+	return nil
+}
+
+// decodeMarkdown reads every checklist item, nested ones included, as a
+// task. An item's text is read like a todo.txt line, and an item without
+// a +project is in the category of the heading above it. Other lines are
+// ignored.
+func decodeMarkdown(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxTextLine)
+
+	rows := []Row{}
+	category := defaultTodoCategory
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if m := checklistHeading.FindStringSubmatch(text); m != nil {
+			category = m[1]
+			if category == "" {
+				category = defaultTodoCategory
+			}
+			continue
+		}
+		m := checklistItem.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+
+		row := Row{Line: line}
+		if m[1] != " " {
+			row.Record.Status = "completed"
+		}
+		parseTodoText(strings.Fields(m[2]), &row)
+		if row.Record.Category == "" {
+			row.Record.Category = category
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,142 @@
+// Package transfer reads and writes tasks in the file formats used to move
+// them between tools.
+package transfer
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+)
+
+// formats
+const (
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatICS      = "ics"
+	FormatTodoTxt  = "todotxt"
+	FormatMarkdown = "markdown"
+)
+
+// Formats lists every format tasks can be exported and imported in.
+var Formats = []string{FormatCSV, FormatJSON, FormatICS, FormatTodoTxt, FormatMarkdown}
+
+var errUnknownFormat = errors.New("unknown format")
+
+// Columns are the fields of an exported task, in CSV column order. ID and
+// the timestamps are informational and ignored on import.
+var Columns = []string{"id", "external_id", "title", "description", "category", "tags", "priority", "status", "due_date", "recurrence", "project_id", "created_at", "updated_at"}
+
+// Record is a task as it appears in an export file.
+type Record struct {
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"external_id,omitempty"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags,omitempty"`
+	Priority    int      `json:"priority"`
+	Status      string   `json:"status"`
+	DueDate     string   `json:"due_date,omitempty"`
+	Recurrence  string   `json:"recurrence,omitempty"`
+	ProjectID   string   `json:"project_id,omitempty"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+}
+
+// NewRecord converts a task for export. A task without an external id is
+// exported under its own id, so importing the file again finds it.
+func NewRecord(task *models.Task) Record {
+	r := Record{
+		ID:          task.ID.Hex(),
+		ExternalID:  task.ExternalID,
+		Title:       task.Title,
+		Description: task.Description,
+		Category:    task.Category,
+		Tags:        task.Tags,
+		Priority:    task.Priority,
+		Status:      task.Status,
+		Recurrence:  task.Recurrence,
+		CreatedAt:   task.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   task.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if r.ExternalID == "" {
+		r.ExternalID = r.ID
+	}
+	if !task.DueDate.IsZero() {
+		r.DueDate = task.DueDate.UTC().Format(time.RFC3339)
+	}
+	if task.ProjectID != nil {
+		r.ProjectID = task.ProjectID.Hex()
+	}
+	return r
+}
+
+// Request is the create request an imported record stands for.
+func (r *Record) Request() *models.CreateTaskRequest {
+	return &models.CreateTaskRequest{
+		ExternalID:  r.ExternalID,
+		Title:       r.Title,
+		Description: r.Description,
+		Category:    r.Category,
+		Tags:        r.Tags,
+		Priority:    r.Priority,
+		Status:      r.Status,
+		DueDate:     r.DueDate,
+		Recurrence:  r.Recurrence,
+		ProjectID:   r.ProjectID,
+	}
+}
+
+// Row is one record read from an import file. Line is the line (CSV,
+// iCalendar, todo.txt, Markdown) or element (JSON) it came from, counting from 1, and Errors
+// holds the problems found while decoding it.
+type Row struct {
+	Line   int
+	Record Record
+	Errors []utils.ValidationErrType
+}
+
+// Encoder writes tasks in one format. Close finishes the file.
+type Encoder interface {
+	Encode(tasks []models.Task) error
+	Close() error
+}
+
+// NewEncoder returns an Encoder for format writing to w, or nil for an
+// unknown format.
+func NewEncoder(format string, w io.Writer) Encoder {
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w)
+	case FormatJSON:
+		return newJSONEncoder(w)
+	case FormatICS:
+		// a file, unlike a feed, keeps the rules so it imports as it was
+		return &icsEncoder{w: w, component: ComponentTodo, rules: true}
+	case FormatTodoTxt:
+		return &todoEncoder{w: w}
+	case FormatMarkdown:
+		return &markdownEncoder{w: w}
+	}
+	return nil
+}
+
+// Decode reads every row of an import file in format.
+func Decode(format string, r io.Reader) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatICS:
+		return decodeICS(r)
+	case FormatTodoTxt:
+		return decodeTodoTxt(r)
+	case FormatMarkdown:
+		return decodeMarkdown(r)
+	}
+	return nil, errUnknownFormat
+}
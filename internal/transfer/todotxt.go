@@ -0,0 +1,201 @@
+package transfer
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"task-manager/internal/models"
+	"task-manager/internal/utils"
+)
+
+// category of plain text tasks that name no +project
+const defaultTodoCategory = "inbox"
+
+// todoPriorities are the todo.txt priority letters of task priorities 5 down
+// to 2. Priority 1 is written without a letter, and every letter after D
+// reads back as 1.
+var todoPriorities = []string{"A", "B", "C", "D"}
+
+func todoPriorityLetter(p int) string {
+	if p < 2 || p > 5 {
+		return ""
+	}
+	return todoPriorities[5-p]
+}
+
+func todoPriority(letter string) int {
+	for i, l := range todoPriorities {
+		if l == letter {
+			return 5 - i
+		}
+	}
+	return 1
+}
+
+var todoPriorityToken = regexp.MustCompile(`^\([A-Z]\)$`)
+
+// todoLead is the (A) to (D) an open task's line or item starts with.
+func todoLead(task *models.Task) []string {
+	letter := todoPriorityLetter(task.Priority)
+	if task.Status == "completed" || letter == "" {
+		return nil
+	}
+	return []string{"(" + letter + ")"}
+}
+
+// todoText renders the rest of a todo.txt line or checklist item: the title
+// followed by +category, @tags and due:, id: and, for a completed task,
+// pri: tags. Descriptions have no place in either format and are left out.
+func todoText(task *models.Task, category bool) []string {
+	parts := []string{task.Title}
+	if category && task.Category != "" {
+		parts = append(parts, "+"+todoWord(task.Category))
+	}
+	for _, tag := range task.Tags {
+		parts = append(parts, "@"+todoWord(tag))
+	}
+	if !task.DueDate.IsZero() {
+		parts = append(parts, "due:"+task.DueDate.UTC().Format(time.DateOnly))
+	}
+	r := NewRecord(task)
+	parts = append(parts, "id:"+todoWord(r.ExternalID))
+	if letter := todoPriorityLetter(task.Priority); task.Status == "completed" && letter != "" {
+		parts = append(parts, "pri:"+letter)
+	}
+	return parts
+}
+
+// todoWord makes s a single word, as projects, contexts and tag values are.
+func todoWord(s string) string {
+	return strings.Join(strings.Fields(s), "-")
+}
+
+// parseTodoText reads the part of a todo.txt line or checklist item after
+// any completion mark and dates. A leading (A) to (Z) is the priority,
+// +project the category, with further projects kept as tags, @context a
+// tag, and due:YYYY-MM-DD the due date. id: is the external id and pri:
+// the priority a completed task had. What is left is the title, which
+// also serves as the description.
+func parseTodoText(words []string, row *Row) {
+	rec := &row.Record
+	rec.Priority = 1
+	invalid := func(path, message string) {
+		row.Errors = append(row.Errors, utils.ValidationErrType{Path: path, Message: message})
+	}
+
+	title := []string{}
+	for i, w := range words {
+		switch {
+		case i == 0 && todoPriorityToken.MatchString(w):
+			rec.Priority = todoPriority(w[1:2])
+		case len(w) > 1 && w[0] == '+':
+			if rec.Category == "" {
+				rec.Category = w[1:]
+			} else {
+				rec.Tags = append(rec.Tags, strings.ToLower(w[1:]))
+			}
+		case len(w) > 1 && w[0] == '@':
+			rec.Tags = append(rec.Tags, strings.ToLower(w[1:]))
+		case strings.HasPrefix(w, "due:"):
+			due, err := time.Parse(time.DateOnly, w[len("due:"):])
+			if err != nil {
+				invalid("due_date", "due_date must be a date such as due:2026-01-02")
+				continue
+			}
+			rec.DueDate = due.Format(time.RFC3339)
+		case strings.HasPrefix(w, "id:") && len(w) > len("id:"):
+			rec.ExternalID = w[len("id:"):]
+		case strings.HasPrefix(w, "pri:") && len(w) == len("pri:")+1:
+			rec.Priority = todoPriority(strings.ToUpper(w[len("pri:"):]))
+		default:
+			title = append(title, w)
+		}
+	}
+
+	rec.Title = strings.Join(title, " ")
+	rec.Description = rec.Title
+	if rec.Status == "" {
+		rec.Status = "pending"
+	}
+}
+
+// todoEncoder writes one todo.txt line per task.
+type todoEncoder struct {
+	w io.Writer
+}
+
+func (e *todoEncoder) Encode(tasks []models.Task) error {
+	var b strings.Builder
+	for i := range tasks {
+		task := &tasks[i]
+		parts := todoLead(task)
+		if task.Status == "completed" {
+			parts = append(parts, "x", task.UpdatedAt.UTC().Format(time.DateOnly))
+		}
+		parts = append(parts, task.CreatedAt.UTC().Format(time.DateOnly))
+		parts = append(parts, todoText(task, true)...)
+		b.WriteString(strings.Join(parts, " ") + "\n")
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *todoEncoder) Close() error {
+	return nil
+}
+
+// decodeTodoTxt reads one task per non-blank line. Completed tasks start
+// with "x" and their completion date; a creation date may come next, or
+// after the priority of an open task, and is ignored.
+func decodeTodoTxt(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxTextLine)
+
+	rows := []Row{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			continue
+		}
+
+		row := Row{Line: line}
+		if words[0] == "x" {
+			row.Record.Status = "completed"
+			words = words[1:]
+		}
+		// dates may follow the mark or the priority
+		start := 0
+		if len(words) > 0 && todoPriorityToken.MatchString(words[0]) && row.Record.Status == "" {
+			start = 1
+		}
+		for range 2 {
+			if len(words) > start && isTodoDate(words[start]) {
+				words = slices.Delete(words, start, start+1)
+			}
+		}
+
+		parseTodoText(words, &row)
+		if row.Record.Category == "" {
+			row.Record.Category = defaultTodoCategory
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// longest line the plain text formats accept
+const maxTextLine = 64 << 10
+
+func isTodoDate(s string) bool {
+	_, err := time.Parse(time.DateOnly, s)
+	return err == nil
+}
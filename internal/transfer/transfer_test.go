@@ -0,0 +1,169 @@
+package transfer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"task-manager/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func exportTasks() []models.Task {
+	project := primitive.NewObjectID()
+	return []models.Task{
+		{
+			ID:          primitive.NewObjectID(),
+			ExternalID:  "ext-1",
+			Title:       `=SUM(A1) café, "quoted"`,
+			Description: "line one\nline two; with, commas",
+			Category:    "work",
+			Tags:        []string{"infra", "ops"},
+			Priority:    5,
+			Status:      "in_progress",
+			DueDate:     time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+			Recurrence:  "FREQ=WEEKLY;BYDAY=MO",
+			ProjectID:   &project,
+		},
+		{
+			ID:          primitive.NewObjectID(),
+			Title:       "Plain",
+			Description: "d",
+			Category:    "home",
+			Priority:    1,
+			Status:      "completed",
+			DueDate:     time.Date(2026, 11, 2, 14, 30, 0, 0, time.UTC),
+		},
+	}
+}
+
+// TestRoundTrip exports tasks in every format and imports them again. Each
+// format keeps only some fields; keep clears the others from the expected
+// records.
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		format string
+		keep   func(r *Record)
+	}{
+		{FormatJSON, func(r *Record) {}},
+		{FormatCSV, func(r *Record) { r.ID, r.CreatedAt, r.UpdatedAt = "", "", "" }},
+		{FormatICS, func(r *Record) { r.ID, r.CreatedAt, r.UpdatedAt, r.ProjectID = "", "", "", "" }},
+		{FormatTodoTxt, plainText},
+		{FormatMarkdown, plainText},
+	}
+
+	tasks := exportTasks()
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewEncoder(tt.format, &buf)
+			if err := e.Encode(tasks); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := Decode(tt.format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != len(tasks) {
+				t.Fatalf("decoded %d rows", len(rows))
+			}
+			for i, row := range rows {
+				if len(row.Errors) > 0 {
+					t.Errorf("row %d: %v", row.Line, row.Errors)
+				}
+				want := NewRecord(&tasks[i])
+				tt.keep(&want)
+				if !reflect.DeepEqual(row.Record, want) {
+					t.Errorf("row %d:\n got %+v\nwant %+v", row.Line, row.Record, want)
+				}
+			}
+		})
+	}
+}
+
+// plainText keeps what a todo list line holds: the title, which is also the
+// description, category, tags, priority, whether the task is done and the
+// day it is due.
+func plainText(r *Record) {
+	r.ID, r.CreatedAt, r.UpdatedAt, r.ProjectID, r.Recurrence = "", "", "", "", ""
+	r.Description = r.Title
+	if r.Status != "completed" {
+		r.Status = "pending"
+	}
+	if r.DueDate != "" {
+		due, _ := time.Parse(time.RFC3339, r.DueDate)
+		r.DueDate = due.Truncate(24 * time.Hour).Format(time.RFC3339)
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		text, cell string
+	}{
+		{"plain", "plain"},
+		{"", ""},
+		{"=1+1", "'=1+1"},
+		{"+31 6 1234", "'+31 6 1234"},
+		{"-2", "'-2"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tindented", "'\tindented"},
+		{"'=already quoted", "''=already quoted"},
+		{"''", "'''"},
+		{"it's", "it's"},
+		{"'", "'"},
+	}
+
+	for _, tt := range tests {
+		if got := csvText(tt.text); got != tt.cell {
+			t.Errorf("csvText(%q) = %q, want %q", tt.text, got, tt.cell)
+		}
+		if got := csvUnquote(tt.cell); got != tt.text {
+			t.Errorf("csvUnquote(%q) = %q, want %q", tt.cell, got, tt.text)
+		}
+	}
+}
+
+func TestICSFolding(t *testing.T) {
+	task := exportTasks()[0]
+	task.Description = strings.Repeat("Grüße aus Köln, ", 30)
+
+	var buf bytes.Buffer
+	e := NewEncoder(FormatICS, &buf)
+	if err := e.Encode([]models.Task{task}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	folded := 0
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > icsLineBytes {
+			t.Errorf("line of %d bytes: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line folded inside a character: %q", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			folded++
+		}
+	}
+	if folded == 0 {
+		t.Fatal("nothing was folded")
+	}
+
+	rows, err := Decode(FormatICS, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Record.Description != task.Description {
+		t.Errorf("decoded %+v", rows)
+	}
+}
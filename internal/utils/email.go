@@ -1,8 +1,13 @@
 package utils
 
 import (
+	"bytes"
+	_ "embed"
 	"fmt"
+	"html"
+	"html/template"
 	"os"
+	"time"
 
 	"github.com/resend/resend-go/v3"
 )
@@ -48,3 +53,120 @@ func SendVerificationEmail(email string, token string) error {
 	fmt.Println("Sent id:", sent.Id)
 	return nil
 }
+
+func SendProjectInvitationEmail(email string, inviter string, projectName string, role string) error {
+	apiKey := os.Getenv("RESEND_API_KEY")
+	client := resend.NewClient(apiKey)
+
+	params := &resend.SendEmailRequest{
+		From:    os.Getenv("EMAIL_SENDER"),
+		To:      []string{email},
+		Subject: fmt.Sprintf("%s shared a project with you", inviter),
+		Html:    fmt.Sprintf("<p>%s added you to the project <b>%s</b> as %s.<br/> You can find it at http://localhost:4000/api/projects</p>", html.EscapeString(inviter), html.EscapeString(projectName), role),
+	}
+
+	sent, err := client.Emails.Send(params)
+	if err != nil {
+		fmt.Println("Error sending email", err)
+		return err
+	}
+
+	fmt.Println("Sent id:", sent.Id)
+	return nil
+}
+
+func SendTaskAssignmentEmail(email string, assigner string, taskTitle string) error {
+	apiKey := os.Getenv("RESEND_API_KEY")
+	client := resend.NewClient(apiKey)
+
+	params := &resend.SendEmailRequest{
+		From:    os.Getenv("EMAIL_SENDER"),
+		To:      []string{email},
+		Subject: fmt.Sprintf("%s assigned you a task", assigner),
+		Html:    fmt.Sprintf("<p>%s assigned you the task <b>%s</b>.<br/> You can find it at http://localhost:4000/api/tasks?assigned=me</p>", html.EscapeString(assigner), html.EscapeString(taskTitle)),
+	}
+
+	sent, err := client.Emails.Send(params)
+	if err != nil {
+		fmt.Println("Error sending email", err)
+		return err
+	}
+
+	fmt.Println("Sent id:", sent.Id)
+	return nil
+}
+
+func SendTaskReminderEmail(email string, taskTitle string, due time.Time) error {
+	apiKey := os.Getenv("RESEND_API_KEY")
+	client := resend.NewClient(apiKey)
+
+	params := &resend.SendEmailRequest{
+		From:    os.Getenv("EMAIL_SENDER"),
+		To:      []string{email},
+		Subject: fmt.Sprintf("Reminder: %s is due soon", taskTitle),
+		Html:    fmt.Sprintf("<p>The task <b>%s</b> is due %s.<br/> You can find it at http://localhost:4000/api/tasks</p>", html.EscapeString(taskTitle), due.UTC().Format("Mon, 02 Jan 2006 15:04 MST")),
+	}
+
+	sent, err := client.Emails.Send(params)
+	if err != nil {
+		fmt.Println("Error sending email", err)
+		return err
+	}
+
+	fmt.Println("Sent id:", sent.Id)
+	return nil
+}
+
+//go:embed templates/digest.html
+var digestHTML string
+
+var digestTemplate = template.Must(template.New("digest").Parse(digestHTML))
+
+// Digest is what a digest email lists.
+type Digest struct {
+	Username  string
+	Frequency string
+	Date      string
+	Sections  []DigestSection
+}
+
+// DigestSection is one list of tasks in a digest. Items holds the first of
+// Total tasks and More counts the rest.
+type DigestSection struct {
+	Name  string
+	Items []DigestItem
+	Total int
+	More  int
+}
+
+type DigestItem struct {
+	Title    string
+	Due      string
+	Priority int
+}
+
+func SendDigestEmail(email string, subject string, digest *Digest) error {
+	var body bytes.Buffer
+	if err := digestTemplate.Execute(&body, digest); err != nil {
+		return err
+	}
+
+	apiKey := os.Getenv("RESEND_API_KEY")
+	client := resend.NewClient(apiKey)
+
+	params := &resend.SendEmailRequest{
+		From:    os.Getenv("EMAIL_SENDER"),
+		To:      []string{email},
+		Subject: subject,
+		Html:    body.String(),
+	}
+
+	sent, err := client.Emails.Send(params)
+	if err != nil {
+		fmt.Println("Error sending email", err)
+		return err
+	}
+
+	fmt.Println("Sent id:", sent.Id)
+	return nil
+}
@@ -35,6 +35,16 @@ func FormatValidationErrors(err error) []ValidationErrType {
 			message = fmt.Sprintf("%s must be less than or equal to %s", field, e.Param())
 		case "rfc3339":
 			message = "must be in RFC3339 format (e.g., 2006-01-02T15:04:05Z or 2006-01-02T15:04:05+05:30)"
+		case "hexcolor":
+			message = fmt.Sprintf("%s must be a hex color (e.g., #1e90ff)", field)
+		case "mongodb":
+			message = fmt.Sprintf("%s must be a valid id", field)
+		case "timezone":
+			message = fmt.Sprintf("%s must be an IANA time zone (e.g., Europe/Berlin)", field)
+		case "datetime":
+			message = fmt.Sprintf("%s must be a time of day (e.g., 08:30)", field)
+		case "rrule":
+			message = "must be a recurrence rule with FREQ=DAILY|WEEKLY|MONTHLY|YEARLY and optional INTERVAL, BYDAY, COUNT or UNTIL (e.g., FREQ=WEEKLY;BYDAY=MO,WE)"
 		default:
 			message = fmt.Sprintf("%s has an invalid value", field)
 		}
@@ -77,6 +87,15 @@ func Internal(msg string, errs any) *AppError {
 func Unauthorized(msg string, errs any) *AppError {
 	return NewAppError(http.StatusUnauthorized, msg, errs)
 }
+
+func Conflict(msg string, errs any) *AppError {
+	return NewAppError(http.StatusConflict, msg, errs)
+}
+
 func Forbidden(msg string, errs any) *AppError {
 	return NewAppError(http.StatusForbidden, msg, errs)
 }
+
+func PreconditionFailed(msg string, errs any) *AppError {
+	return NewAppError(http.StatusPreconditionFailed, msg, errs)
+}
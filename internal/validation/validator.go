@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"task-manager/internal/recurrence"
 )
 
 var Validate *validator.Validate
@@ -27,4 +28,12 @@ func init() {
 		_, err := time.Parse(time.RFC3339, fl.Field().String())
 		return err == nil
 	})
+
+	Validate.RegisterValidation("rrule", func(fl validator.FieldLevel) bool {
+		if fl.Field().String() == "" {
+			return true
+		}
+		_, err := recurrence.Parse(fl.Field().String())
+		return err == nil
+	})
 }